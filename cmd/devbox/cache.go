@@ -0,0 +1,15 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache shared between projects",
+	Long:  "Provides commands to manage the git cache used by sources with sharedCache enabled",
+}
+
+func init() {
+	root.AddCommand(cacheCmd)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pilat/devbox/internal/app"
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/source"
+	"github.com/pilat/devbox/internal/table"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove cached repositories that no project uses",
+		Long:  "That command removes cached repositories that no source of any project borrows objects from",
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: validArgsWrapper(
+			func(ctx context.Context, cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			},
+		),
+		RunE: runWrapper(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			if err := runCachePrune(); err != nil {
+				return fmt.Errorf("failed to prune cache: %w", err)
+			}
+
+			return nil
+		}),
+	}
+
+	cacheCmd.AddCommand(cmd)
+}
+
+func runCachePrune() error {
+	root := source.GitCacheDir()
+
+	caches, err := git.ListCaches(root)
+	if err != nil {
+		return fmt.Errorf("failed to list caches: %w", err)
+	}
+
+	projects, err := mgr.List("")
+	if err != nil {
+		return fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	// A cache is in use while any source of any project borrows objects from it
+	repoDirs := []string{}
+	for _, name := range projects {
+		sourcesDir := filepath.Join(app.AppDir, name, app.SourcesDir)
+
+		entries, err := os.ReadDir(sourcesDir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			repoDirs = append(repoDirs, filepath.Join(sourcesDir, entry.Name()))
+		}
+	}
+
+	used := git.ReferencedCaches(repoDirs)
+
+	fmt.Println("[*] Pruning cache...")
+
+	t := table.New("Repository", "Result")
+	t.SortBy([]table.SortBy{{Name: "Repository", Mode: table.Asc}})
+
+	for _, cache := range caches {
+		name, _ := filepath.Rel(root, cache)
+
+		if used[cache] {
+			t.AppendRow(name, "in use")
+			continue
+		}
+
+		if err := os.RemoveAll(cache); err != nil {
+			return fmt.Errorf("failed to remove cache %s: %w", name, err)
+		}
+
+		removeEmptyParents(filepath.Dir(cache), root)
+
+		t.AppendRow(name, "removed")
+	}
+
+	if len(caches) == 0 {
+		fmt.Println("Cache is empty")
+		return nil
+	}
+
+	fmt.Println("")
+	t.Render()
+
+	return nil
+}
+
+// removeEmptyParents removes dir and its parents up to root while they are empty.
+func removeEmptyParents(dir, root string) {
+	for dir != root && len(dir) > len(root) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/table"
+)
+
+// maxChangelogCommits limits the commits printed per repository, the JSON changelog has all of them.
+const maxChangelogCommits = 10
+
+// changelogEntry describes what a sync brought into the manifest repository or a source.
+type changelogEntry struct {
+	Name    string   `json:"name"`
+	From    string   `json:"from,omitempty"` // empty when the sync cloned the source
+	To      string   `json:"to"`
+	Commits []string `json:"commits"` // as "<short hash> <subject>", newest first
+	Files   int      `json:"files"`
+}
+
+func (e changelogEntry) isChanged() bool {
+	return e.From != e.To
+}
+
+// changelogReader is implemented by git.Service and by source backends with history.
+type changelogReader interface {
+	GetChangelog(ctx context.Context, from, to string) (*git.Changelog, error)
+}
+
+// readChangelog describes the move of a repository from one commit to another. There is nothing to compare a
+// fresh clone with, so it has no commits.
+func readChangelog(ctx context.Context, r changelogReader, name, from, to string) (changelogEntry, error) {
+	entry := changelogEntry{Name: name, From: from, To: to, Commits: []string{}}
+	if from == "" || !entry.isChanged() {
+		return entry, nil
+	}
+
+	c, err := r.GetChangelog(ctx, from, to)
+	if err != nil {
+		return changelogEntry{}, fmt.Errorf("failed to get changelog of %s: %w", name, err)
+	}
+
+	if c.Commits != nil {
+		entry.Commits = c.Commits
+	}
+
+	entry.Files = c.Files
+
+	return entry, nil
+}
+
+// changelog is the JSON document written by `devbox update --changelog-json`.
+type changelog struct {
+	Manifest *changelogEntry  `json:"manifest,omitempty"`
+	Sources  []changelogEntry `json:"sources"`
+}
+
+func newChangelog(manifest *changelogEntry, sources []changelogEntry) *changelog {
+	sorted := append([]changelogEntry{}, sources...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	return &changelog{Manifest: manifest, Sources: sorted}
+}
+
+func printChangelog(c *changelog) {
+	t := table.New("Name", "Range", "Files", "Commits")
+
+	changed := 0
+	if c.Manifest != nil && c.Manifest.isChanged() {
+		changed++
+		e := c.Manifest
+		t.AppendRow(e.Name+" (manifest)", formatChangelogRange(*e), e.Files, formatChangelogCommits(e.Commits))
+	}
+
+	for _, e := range c.Sources {
+		if !e.isChanged() {
+			continue
+		}
+
+		changed++
+		t.AppendRow(e.Name, formatChangelogRange(e), e.Files, formatChangelogCommits(e.Commits))
+	}
+
+	fmt.Println("")
+
+	if changed == 0 {
+		fmt.Println("No incoming changes")
+		return
+	}
+
+	fmt.Println(" Changes:")
+	t.Render()
+}
+
+func formatChangelogRange(e changelogEntry) string {
+	to := e.To[:min(len(e.To), 7)]
+	if e.From == "" {
+		return to + " (new)"
+	}
+
+	return e.From[:min(len(e.From), 7)] + ".." + to
+}
+
+func formatChangelogCommits(commits []string) string {
+	if len(commits) <= maxChangelogCommits {
+		return strings.Join(commits, "\n")
+	}
+
+	lines := append([]string{}, commits[:maxChangelogCommits]...)
+	lines = append(lines, fmt.Sprintf("... and %d more", len(commits)-maxChangelogCommits))
+
+	return strings.Join(lines, "\n")
+}
+
+func writeChangelogJSON(c *changelog, filename string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal changelog: %w", err)
+	}
+
+	if filename == "-" {
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if err := os.WriteFile(filename, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write changelog: %w", err)
+	}
+
+	return nil
+}
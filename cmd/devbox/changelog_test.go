@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pilat/devbox/internal/git"
+)
+
+func TestReadChangelog(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		changes  *git.Changelog
+		want     changelogEntry
+		wantCall bool
+	}{
+		{
+			name: "up to date",
+			from: "1111",
+			to:   "1111",
+			want: changelogEntry{Name: "api", From: "1111", To: "1111", Commits: []string{}},
+		},
+		{
+			name: "cloned",
+			to:   "2222",
+			want: changelogEntry{Name: "api", To: "2222", Commits: []string{}},
+		},
+		{
+			name:    "new commits",
+			from:    "1111",
+			to:      "2222",
+			changes: &git.Changelog{Commits: []string{"2222 Fix login"}, Files: 2},
+			want: changelogEntry{
+				Name: "api", From: "1111", To: "2222", Commits: []string{"2222 Fix login"}, Files: 2,
+			},
+			wantCall: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := git.NewMockService(t)
+			if tt.wantCall {
+				g.EXPECT().GetChangelog(mock.Anything, tt.from, tt.to).Return(tt.changes, nil)
+			}
+
+			got, err := readChangelog(context.Background(), g, "api", tt.from, tt.to)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatChangelog(t *testing.T) {
+	assert.Equal(t, "1111111..2222222", formatChangelogRange(changelogEntry{From: "11111111aa", To: "22222222bb"}))
+	assert.Equal(t, "2222222 (new)", formatChangelogRange(changelogEntry{To: "22222222bb"}))
+
+	commits := make([]string, 12)
+	for i := range commits {
+		commits[i] = "abc1234 change"
+	}
+
+	lines := strings.Split(formatChangelogCommits(commits), "\n")
+	assert.Len(t, lines, maxChangelogCommits+1)
+	assert.Equal(t, "... and 2 more", lines[maxChangelogCommits])
+}
+
+func TestWriteChangelogJSON(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "changelog.json")
+	c := newChangelog(
+		&changelogEntry{Name: "demo", From: "1111", To: "1111", Commits: []string{}},
+		[]changelogEntry{
+			{Name: "web", From: "3333", To: "4444", Commits: []string{"4444 Add page"}, Files: 1},
+			{Name: "api", To: "2222", Commits: []string{}},
+		},
+	)
+
+	require.NoError(t, writeChangelogJSON(c, filename))
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	got := &changelog{}
+	require.NoError(t, json.Unmarshal(data, got))
+	assert.Equal(t, c, got)
+	assert.Equal(t, "api", got.Sources[0].Name)
+}
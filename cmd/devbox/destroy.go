@@ -2,14 +2,29 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/pilat/devbox/internal/app"
 	"github.com/pilat/devbox/internal/project"
+	"github.com/pilat/devbox/internal/table"
 )
 
+// destroyOptions tells what destroy removes besides the containers and the project itself.
+type destroyOptions struct {
+	keepData     bool // keep the volumes
+	removeImages bool // remove the images built by the project
+}
+
 func init() {
+	var opts destroyOptions
+
 	cmd := &cobra.Command{
 		Use:   "destroy",
 		Short: "Destroy devbox project",
@@ -25,13 +40,7 @@ func init() {
 				return fmt.Errorf("failed to detect project: %w", err)
 			}
 
-			if err := runDown(ctx, p, true); err != nil {
-				return fmt.Errorf("failed to stop project: %w", err)
-			}
-
-			_ = runHostsUpdate(p, true, true)
-
-			if err := runDestroy(ctx, p); err != nil {
+			if err := runDestroy(ctx, p, opts); err != nil {
 				return fmt.Errorf("failed to destroy project: %w", err)
 			}
 
@@ -39,16 +48,123 @@ func init() {
 		}),
 	}
 
+	cmd.Flags().BoolVar(&opts.keepData, "keep-data", false, "Keep the volumes of the project")
+	cmd.Flags().BoolVar(&opts.removeImages, "remove-images", false, "Remove the images built by the project")
+
 	root.AddCommand(cmd)
 }
 
-func runDestroy(ctx context.Context, p *project.Project) error {
+// runDestroy tears the project down: containers first, so nothing holds the volumes or images, then the hosts
+// block, the certificates and the worktrees, the project directory goes last.
+func runDestroy(ctx context.Context, p *project.Project, opts destroyOptions) error {
+	t := table.New("Resource", "Result")
+
+	downOpts := project.DownOptions{Volumes: !opts.keepData}
+	if opts.removeImages {
+		// Compose removes every image it built for the project, with or without an image name of the service, and
+		// the images of removed services too, as down removes orphans. Images of services that are only pulled stay.
+		downOpts.Images = "local"
+	}
+
+	if err := runDown(ctx, p, downOpts); err != nil {
+		return fmt.Errorf("failed to stop project: %w", err)
+	}
+
+	t.AppendRow("Containers and networks", "removed")
+	t.AppendRow("Volumes", keptOrRemoved(!opts.keepData))
+	t.AppendRow("Images", keptOrRemoved(opts.removeImages))
+
+	// Even a project without hosts now may have left a block behind, removing a missing block writes nothing
+	fmt.Println("[*] Removing hosts...")
+	if err := runHostsUpdate(p, true, true); err != nil {
+		t.AppendRow("Hosts", "failed: "+err.Error())
+	} else {
+		t.AppendRow("Hosts", "removed")
+	}
+
+	if len(p.CertConfig.Domains) > 0 {
+		fmt.Println("[*] Removing certificates...")
+		if err := removeCerts(p); err != nil {
+			t.AppendRow("Certificates", "failed: "+err.Error())
+		} else {
+			t.AppendRow("Certificates", "removed")
+		}
+	}
+
+	// Worktrees of the developer's own clones are registered there, removing only the directories would leave their
+	// branches checked out. Nothing runs anymore, so every worktree goes.
+	if len(p.Worktrees) > 0 {
+		if err := removeWorktrees(ctx, p, maps.Clone(p.Worktrees), false); err != nil {
+			return fmt.Errorf("failed to remove worktrees: %w", err)
+		}
+
+		t.AppendRow("Worktrees", "removed")
+	}
+
+	backupsDir, err := keepBackups(p)
+	if err != nil {
+		return err
+	}
+
+	if backupsDir != "" {
+		t.AppendRow("Backups", "moved to "+backupsDir)
+	}
+
 	fmt.Println("[*] Removing project...")
 	if err := mgr.Destroy(ctx, p); err != nil {
 		return fmt.Errorf("failed to remove project: %w", err)
 	}
 
+	t.AppendRow("Project", "removed")
+
 	fmt.Println("")
+	t.Render()
+
+	fmt.Printf("\nProject '%s' has been destroyed.\n", p.Name)
 
 	return nil
 }
+
+// removeCerts removes the certificate and the key generated for the project. The CA is shared by all projects
+// and stays.
+func removeCerts(p *project.Project) error {
+	for _, file := range []string{p.CertConfig.CertFile, p.CertConfig.KeyFile} {
+		if file == "" {
+			continue
+		}
+
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+// keepBackups moves the backups of local changes out of the project directory before it's removed, and returns
+// where they are now.
+func keepBackups(p *project.Project) (string, error) {
+	dir := filepath.Join(p.WorkingDir, app.SourcesDir, project.BackupsDir)
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+
+	target := filepath.Join(app.AppDir, app.BackupsDir, p.Name, time.Now().Format("20060102-150405"))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create backups directory: %w", err)
+	}
+
+	if err := os.Rename(dir, target); err != nil {
+		return "", fmt.Errorf("failed to keep backups: %w", err)
+	}
+
+	return target, nil
+}
+
+func keptOrRemoved(removed bool) string {
+	if removed {
+		return "removed"
+	}
+
+	return "kept"
+}
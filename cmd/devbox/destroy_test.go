@@ -0,0 +1,85 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+
+	"github.com/pilat/devbox/internal/app"
+	"github.com/pilat/devbox/internal/project"
+)
+
+func TestKeepBackups(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	appDir := app.AppDir
+	app.AppDir = t.TempDir()
+	t.Cleanup(func() { app.AppDir = appDir })
+
+	repo := t.TempDir()
+	runGit := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_CONFIG_GLOBAL=/dev/null",
+			"GIT_CONFIG_SYSTEM=/dev/null",
+			"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t",
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+
+	runGit("init", "-q")
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit("add", "main.go")
+	runGit("commit", "-q", "-m", "init")
+
+	p := &project.Project{Project: &types.Project{Name: "shop", WorkingDir: t.TempDir()}}
+	dir := p.SourceWorktreeDir("api", "fix")
+	runGit("worktree", "add", "-q", "-b", "fix", dir)
+	p.Worktrees = map[string]string{dir: repo}
+	p.LocalMounts = map[string]string{"./sources/api": dir}
+
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main // wip\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := removeWorktrees(t.Context(), p, maps.Clone(p.Worktrees), false); err != nil {
+		t.Fatalf("removeWorktrees() error = %v", err)
+	}
+
+	if strings.Contains(runGit("worktree", "list"), dir) {
+		t.Errorf("worktree %s is still registered in %s", dir, repo)
+	}
+
+	got, err := keepBackups(p)
+	if err != nil {
+		t.Fatalf("keepBackups() error = %v", err)
+	}
+
+	if !strings.HasPrefix(got, filepath.Join(app.AppDir, app.BackupsDir, "shop")+string(filepath.Separator)) {
+		t.Fatalf("keepBackups() = %q, want a directory of the project in %s", got, app.AppDir)
+	}
+
+	patches, _ := filepath.Glob(filepath.Join(got, "api", "*", "changes.patch"))
+	if len(patches) != 1 {
+		t.Fatalf("backup of the worktree is not kept in %s: %v", got, patches)
+	}
+
+	if got, err := keepBackups(p); got != "" || err != nil {
+		t.Errorf("keepBackups() without backups = %q, %v", got, err)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/pilat/devbox/internal/manager"
+	"github.com/pilat/devbox/internal/settings"
 )
 
 const (
@@ -34,6 +35,7 @@ var (
 
 func main() {
 	for _, fn := range []func() error{
+		initSettings,
 		initDocker,
 		initCobra,
 	} {
@@ -48,6 +50,8 @@ func initCobra() error {
 	root.SetErrPrefix("Error has occurred while executing the command:")
 
 	root.PersistentFlags().StringVarP(&projectName, "name", "n", "", "Project name")
+	root.PersistentFlags().BoolVar(&offline, "offline", settings.Offline,
+		"Use the project and its sources as they are on disk, without pulling or fetching")
 
 	_ = root.RegisterFlagCompletionFunc(
 		"name",
@@ -64,6 +68,22 @@ func initCobra() error {
 	return nil
 }
 
+// initSettings runs before anything else, the settings may move the data root. Nothing reports the error yet,
+// so it's printed here.
+func initSettings() error {
+	path, err := settings.Path()
+	if err == nil {
+		err = settings.Load(path)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to load settings:", err)
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	return nil
+}
+
 func initDocker() error {
 	var err error
 	dockerCLI, err = command.NewDockerCli()
@@ -0,0 +1,495 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/moby/moby/client"
+	"github.com/spf13/cobra"
+
+	"github.com/pilat/devbox/internal/app"
+	"github.com/pilat/devbox/internal/cert"
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/hosts"
+	"github.com/pilat/devbox/internal/project"
+	"github.com/pilat/devbox/internal/table"
+)
+
+// The requirements from the README. Docker Engine 20.10 speaks the API 1.41.
+const (
+	minDockerVersion    = "20.10.0"
+	minDockerAPIVersion = "1.41"
+	minGitVersion       = "2.28"
+)
+
+type checkStatus string
+
+const (
+	checkPass checkStatus = "pass"
+	checkWarn checkStatus = "warn"
+	checkFail checkStatus = "fail"
+)
+
+type checkResult struct {
+	Name    string      `json:"name"`
+	Status  checkStatus `json:"status"`
+	Message string      `json:"message"`
+	Hint    string      `json:"hint,omitempty"` // how to fix it, empty when it passed
+}
+
+func init() {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the environment",
+		Long:  "That command will check Docker, git, certificates, hosts, ports and the project state",
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return []string{}, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: runWrapper(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			results := runDoctor(ctx)
+
+			if jsonOutput {
+				data, err := json.MarshalIndent(results, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal results: %w", err)
+				}
+
+				fmt.Println(string(data))
+			} else {
+				printDoctorResults(results)
+			}
+
+			failed := 0
+			for _, r := range results {
+				if r.Status == checkFail {
+					failed++
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d checks failed", failed, len(results))
+			}
+
+			return nil
+		}),
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the results as JSON")
+
+	root.AddCommand(cmd)
+}
+
+// runDoctor runs the checks of the environment, then the ones of the project when there is one. Doctor must work
+// when nothing else does, so no check stops the others.
+func runDoctor(ctx context.Context) []checkResult {
+	results := checkDocker(ctx)
+	results = append(results, checkGit(ctx), checkAppDir())
+
+	p, err := mgr.AutodetectProject(ctx, projectName)
+	if err != nil {
+		status := checkWarn
+		if projectName != "" {
+			status = checkFail
+		}
+
+		results = append(results, checkCA(nil), checkResult{
+			Name:    "Project",
+			Status:  status,
+			Message: err.Error(),
+			Hint:    "Run doctor inside a project or pass --name, to check the project too",
+		})
+
+		return results
+	}
+
+	results = append(results,
+		checkCA(p),
+		checkHosts(p),
+		checkPorts(ctx, p),
+		checkState(p),
+		checkMounts(p),
+	)
+
+	return results
+}
+
+func printDoctorResults(results []checkResult) {
+	t := table.New("Check", "Status", "Details")
+
+	for _, r := range results {
+		details := r.Message
+		if r.Hint != "" {
+			details += "\n" + r.Hint
+		}
+
+		t.AppendRow(r.Name, string(r.Status), details)
+	}
+
+	t.Render()
+}
+
+func checkDocker(ctx context.Context) []checkResult {
+	v, err := dockerClient.ServerVersion(ctx, client.ServerVersionOptions{})
+	if err != nil {
+		return []checkResult{{
+			Name:    "Docker Engine",
+			Status:  checkFail,
+			Message: err.Error(),
+			Hint:    "Start Docker and check that the current user can access it",
+		}}
+	}
+
+	engine := checkResult{Name: "Docker Engine", Status: checkPass, Message: v.Version}
+	if compareVersions(v.Version, minDockerVersion) < 0 {
+		engine.Status = checkFail
+		engine.Hint = "Upgrade Docker Engine to " + minDockerVersion + " or later"
+	}
+
+	api := checkResult{
+		Name:    "Docker API",
+		Status:  checkPass,
+		Message: fmt.Sprintf("%s, compose uses %s", v.APIVersion, dockerClient.ClientVersion()),
+	}
+	if compareVersions(v.APIVersion, minDockerAPIVersion) < 0 {
+		api.Status = checkFail
+		api.Hint = "Upgrade Docker Engine, compose needs the API " + minDockerAPIVersion + " or later"
+	}
+
+	return []checkResult{engine, api}
+}
+
+func checkGit(ctx context.Context) checkResult {
+	v, err := git.Version(ctx)
+	if err != nil {
+		return checkResult{Name: "Git", Status: checkFail, Message: err.Error(), Hint: "Install git"}
+	}
+
+	if compareVersions(v, minGitVersion) < 0 {
+		return checkResult{
+			Name:    "Git",
+			Status:  checkFail,
+			Message: v,
+			Hint:    "Upgrade git to " + minGitVersion + " or later",
+		}
+	}
+
+	return checkResult{Name: "Git", Status: checkPass, Message: v}
+}
+
+// checkAppDir tells whether devbox can write its data, without leaving anything behind.
+func checkAppDir() checkResult {
+	r := checkResult{Name: "Data directory", Status: checkPass, Message: app.AppDir}
+
+	if _, err := os.Stat(app.AppDir); errors.Is(err, os.ErrNotExist) {
+		r.Status = checkWarn
+		r.Message = app.AppDir + " does not exist yet"
+		r.Hint = "Run 'devbox init', it creates the directory"
+		return r
+	}
+
+	f, err := os.CreateTemp(app.AppDir, ".doctor-*")
+	if err != nil {
+		r.Status = checkFail
+		r.Message = app.AppDir + " is not writable"
+		r.Hint = "Fix the owner of the directory, e.g. 'sudo chown -R $USER " + app.AppDir + "'"
+		return r
+	}
+
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+
+	return r
+}
+
+// checkCA reports the trust state of the CA. It only matters to the projects that use certificates.
+func checkCA(p *project.Project) checkResult {
+	r := checkResult{Name: "CA certificate", Status: checkPass}
+	needed := p != nil && len(p.CertConfig.Domains) > 0
+
+	state, err := cert.CheckCA(app.AppDir)
+	switch {
+	case err != nil:
+		r.Status, r.Message = checkFail, err.Error()
+		r.Hint = "Remove ca.crt and ca.key from " + app.AppDir + " and run 'devbox up' to generate a new CA"
+	case state == cert.CATrusted:
+		r.Message = "trusted"
+	case state == cert.CAMissing && !needed:
+		r.Message = "not used"
+	case state == cert.CAMissing:
+		r.Status, r.Message = checkWarn, "not generated yet"
+		r.Hint = "Run 'devbox up', it generates and installs the CA"
+	case state == cert.CAExpired:
+		r.Status, r.Message = checkWarn, "expired"
+		r.Hint = "Run 'devbox up', it generates and installs a new CA"
+	case needed:
+		r.Status, r.Message = checkFail, "not trusted by the system"
+		r.Hint = "Run 'devbox up' and allow sudo, it installs the CA"
+	default:
+		r.Status, r.Message = checkWarn, "not trusted by the system"
+		r.Hint = "Run 'devbox up' of a project with certificates and allow sudo, it installs the CA"
+	}
+
+	return r
+}
+
+func checkHosts(p *project.Project) checkResult {
+	r := checkResult{Name: "Hosts", Status: checkPass}
+
+	entries, err := hosts.Read(p.Name)
+	if err != nil {
+		r.Status, r.Message = checkFail, err.Error()
+		r.Hint = "Fix the devbox block of the project in /etc/hosts by hand"
+		return r
+	}
+
+	switch {
+	case len(entries) == 0 && len(p.HostEntities) == 0:
+		r.Message = "not used"
+	case slices.Equal(entries, p.HostEntities):
+		r.Message = fmt.Sprintf("%d entries up to date", len(entries))
+	default:
+		r.Status = checkFail
+		r.Message = fmt.Sprintf("block has %d entries, the project needs %d", len(entries), len(p.HostEntities))
+		r.Hint = fmt.Sprintf("Run 'devbox up' or 'sudo devbox update-hosts --name %s'", p.Name)
+	}
+
+	return r
+}
+
+// publishedPort is a port a service publishes on the host.
+type publishedPort struct {
+	service  string
+	hostIP   string
+	port     int
+	protocol string
+}
+
+func (pp publishedPort) String() string {
+	return fmt.Sprintf("%d/%s (%s)", pp.port, pp.protocol, pp.service)
+}
+
+// checkPorts tells whether the published ports are free. The ones the running project publishes itself are
+// fine.
+func checkPorts(ctx context.Context, p *project.Project) checkResult {
+	r := checkResult{Name: "Ports", Status: checkPass}
+
+	ports := publishedPorts(p)
+	if len(ports) == 0 {
+		r.Message = "not used"
+		return r
+	}
+
+	own := map[int]bool{}
+	if containers, err := apiService.Ps(ctx, p.Name, project.PsOptions{Project: p.Project}); err == nil {
+		for _, c := range containers {
+			for _, pub := range c.Publishers {
+				own[pub.PublishedPort] = true
+			}
+		}
+	}
+
+	var busy, unchecked []string
+	for _, pp := range ports {
+		if own[pp.port] {
+			continue
+		}
+
+		free, err := isPortFree(pp)
+		if err != nil {
+			unchecked = append(unchecked, fmt.Sprintf("%s (%v)", pp, err))
+		} else if !free {
+			busy = append(busy, pp.String())
+		}
+	}
+
+	if len(busy) > 0 {
+		r.Status = checkFail
+		r.Message = "in use: " + strings.Join(busy, ", ")
+		r.Hint = "Stop what listens on these ports, e.g. another project: 'devbox --name <project> down'"
+		return r
+	}
+
+	// Docker publishes ports with its own privileges, the port may still be available to it
+	if len(unchecked) > 0 {
+		r.Status = checkWarn
+		r.Message = "can't check: " + strings.Join(unchecked, ", ")
+		r.Hint = "Make sure nothing else listens on these ports"
+		return r
+	}
+
+	r.Message = fmt.Sprintf("%d published ports available", len(ports))
+
+	return r
+}
+
+func publishedPorts(p *project.Project) []publishedPort {
+	var ports []publishedPort
+
+	for _, svc := range p.Services {
+		for _, cfg := range svc.Ports {
+			if cfg.Published == "" {
+				continue // a random port is picked by Docker
+			}
+
+			protocol := cfg.Protocol
+			if protocol == "" {
+				protocol = "tcp"
+			}
+
+			start, end, _ := strings.Cut(cfg.Published, "-")
+			from, err := strconv.Atoi(start)
+			if err != nil {
+				continue
+			}
+
+			to := from
+			if end != "" {
+				if to, err = strconv.Atoi(end); err != nil {
+					continue
+				}
+			}
+
+			for port := from; port <= to; port++ {
+				ports = append(ports, publishedPort{
+					service:  svc.Name,
+					hostIP:   cfg.HostIP,
+					port:     port,
+					protocol: protocol,
+				})
+			}
+		}
+	}
+
+	sort.Slice(ports, func(i, j int) bool { return ports[i].port < ports[j].port })
+
+	return ports
+}
+
+// isPortFree tells whether the port can be listened on. Only a port that is in use counts as taken, any other
+// failure, e.g. a privileged port, is returned.
+func isPortFree(pp publishedPort) (bool, error) {
+	addr := net.JoinHostPort(pp.hostIP, strconv.Itoa(pp.port))
+
+	var err error
+	if pp.protocol == "udp" {
+		var conn net.PacketConn
+		if conn, err = net.ListenPacket("udp", addr); err == nil {
+			_ = conn.Close()
+		}
+	} else {
+		var l net.Listener
+		if l, err = net.Listen("tcp", addr); err == nil {
+			_ = l.Close()
+		}
+	}
+
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// checkState looks for the entries of the state file that don't match the manifest anymore.
+func checkState(p *project.Project) checkResult {
+	var problems []string
+
+	for _, mountPath := range sortedKeys(p.UnknownMounts()) {
+		problems = append(problems, "mount of unknown source "+mountPath)
+	}
+
+	for _, name := range p.UnknownOverrides() {
+		problems = append(problems, "checkout of unknown source "+name)
+	}
+
+	for _, worktree := range sortedKeys(p.UnusedWorktrees()) {
+		problems = append(problems, "unused worktree "+worktree)
+	}
+
+	if len(problems) == 0 {
+		return checkResult{Name: "State", Status: checkPass, Message: "consistent"}
+	}
+
+	return checkResult{
+		Name:    "State",
+		Status:  checkWarn,
+		Message: strings.Join(problems, "\n"),
+		Hint:    "Run 'devbox gc --apply' to clean them up",
+	}
+}
+
+func checkMounts(p *project.Project) checkResult {
+	dangling := p.DanglingMounts()
+	if len(dangling) == 0 {
+		message := fmt.Sprintf("%d mounts found", len(p.LocalMounts))
+		return checkResult{Name: "Mounts", Status: checkPass, Message: message}
+	}
+
+	var missing []string
+	for _, mountPath := range sortedKeys(dangling) {
+		missing = append(missing, fmt.Sprintf("%s: %s", mountPath, dangling[mountPath]))
+	}
+
+	return checkResult{
+		Name:    "Mounts",
+		Status:  checkFail,
+		Message: "missing local paths:\n" + strings.Join(missing, "\n"),
+		Hint:    "Run 'devbox umount --source <mount path>' or mount an existing path again",
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// compareVersions compares dotted versions by their numbers, "20.10.24+dfsg1" and "2.39.2.windows.1" included.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		if c := versionPart(as, i) - versionPart(bs, i); c != 0 {
+			if c < 0 {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// versionPart returns the leading number of the i-th part, 0 when there is none.
+func versionPart(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+
+	digits := strings.IndexFunc(parts[i], func(r rune) bool { return r < '0' || r > '9' })
+	if digits == -1 {
+		digits = len(parts[i])
+	}
+
+	n, _ := strconv.Atoi(parts[i][:digits])
+
+	return n
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pilat/devbox/internal/project"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"20.10.0", "20.10.0", 0},
+		{"27.3.1", "20.10.0", 1},
+		{"19.03.12", "20.10.0", -1},
+		{"20.10.24+dfsg1", "20.10.0", 1},
+		{"2.28", "2.28.0", 0},
+		{"2.39.2.windows.1", "2.28", 1},
+		{"2.25.1", "2.28", -1},
+		{"1.47", "1.41", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, compareVersions(tt.a, tt.b))
+		})
+	}
+}
+
+func TestPublishedPorts(t *testing.T) {
+	p := &project.Project{Project: &types.Project{Services: types.Services{
+		"web": {Name: "web", Ports: []types.ServicePortConfig{
+			{Target: 80, Published: "8080"},
+			{Target: 53, Published: "5353", Protocol: "udp", HostIP: "127.0.0.1"},
+		}},
+		"api": {Name: "api", Ports: []types.ServicePortConfig{
+			{Target: 9000, Published: "9000-9001"},
+			{Target: 9100}, // random port
+		}},
+	}}}
+
+	assert.Equal(t, []publishedPort{
+		{service: "web", port: 5353, protocol: "udp", hostIP: "127.0.0.1"},
+		{service: "web", port: 8080, protocol: "tcp"},
+		{service: "api", port: 9000, protocol: "tcp"},
+		{service: "api", port: 9001, protocol: "tcp"},
+	}, publishedPorts(p))
+}
+
+func TestIsPortFree(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	port := l.Addr().(*net.TCPAddr).Port
+	pp := publishedPort{service: "web", hostIP: "127.0.0.1", port: port, protocol: "tcp"}
+
+	free, err := isPortFree(pp)
+	require.NoError(t, err)
+	assert.False(t, free)
+
+	require.NoError(t, l.Close())
+	free, err = isPortFree(pp)
+	require.NoError(t, err)
+	assert.True(t, free)
+}
+
+func TestIsPortFree_PrivilegedPort(t *testing.T) {
+	start, err := os.ReadFile("/proc/sys/net/ipv4/ip_unprivileged_port_start")
+	if os.Geteuid() == 0 || err != nil || strings.TrimSpace(string(start)) == "0" {
+		t.Skip("privileged ports can be listened on")
+	}
+
+	free, err := isPortFree(publishedPort{service: "web", hostIP: "127.0.0.1", port: 80, protocol: "tcp"})
+	require.ErrorIs(t, err, syscall.EACCES)
+	assert.False(t, free)
+}
@@ -25,10 +25,18 @@ func init() {
 				return fmt.Errorf("failed to detect project: %w", err)
 			}
 
-			if err := runDown(ctx, p, false); err != nil {
+			if err := runHooks(ctx, p, project.HookPreDown); err != nil {
+				return fmt.Errorf("failed to run hooks: %w", err)
+			}
+
+			if err := runDown(ctx, p, project.DownOptions{}); err != nil {
 				return fmt.Errorf("failed to stop project: %w", err)
 			}
 
+			if err := runHooks(ctx, p, project.HookPostDown); err != nil {
+				return fmt.Errorf("failed to run hooks: %w", err)
+			}
+
 			return nil
 		}),
 	}
@@ -36,13 +44,12 @@ func init() {
 	root.AddCommand(cmd)
 }
 
-func runDown(ctx context.Context, p *project.Project, deleteVolumes bool) error {
+// runDown stops and removes the containers and networks of the project, opts may ask to remove its volumes and
+// images too.
+func runDown(ctx context.Context, p *project.Project, opts project.DownOptions) error {
 	// we are not overriding timeout allowing users to define it with stop_grace_period by user
-	opts := project.DownOptions{
-		Project:       p.Project,
-		RemoveOrphans: true,
-		Volumes:       deleteVolumes,
-	}
+	opts.Project = p.Project
+	opts.RemoveOrphans = true
 
 	svc, err := newProgressCompose()
 	if err != nil {
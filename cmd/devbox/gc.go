@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/docker/compose/v5/pkg/api"
+	"github.com/moby/moby/api/types/image"
+	"github.com/moby/moby/api/types/volume"
+	"github.com/moby/moby/client"
+	"github.com/spf13/cobra"
+
+	"github.com/pilat/devbox/internal/project"
+	"github.com/pilat/devbox/internal/table"
+)
+
+// garbage is something gc found that the project doesn't use anymore.
+type garbage struct {
+	kind   string // source, mount, checkout, worktree, volume or image
+	name   string
+	remove func(ctx context.Context) error
+}
+
+func init() {
+	var apply, force bool
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove what the project doesn't use anymore",
+		Long: "That command will find orphaned sources, invalid mounts, and the images and volumes of the project " +
+			"that no service uses. Nothing is removed without --apply",
+		ValidArgsFunction: validArgsWrapper(
+			func(ctx context.Context, cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			},
+		),
+		RunE: runWrapper(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			p, err := mgr.AutodetectProject(ctx, projectName)
+			if err != nil {
+				return fmt.Errorf("failed to detect project: %w", err)
+			}
+
+			if err := runGC(ctx, p, apply, force); err != nil {
+				return fmt.Errorf("failed to collect garbage: %w", err)
+			}
+
+			return nil
+		}),
+	}
+
+	cmd.Flags().BoolVar(&apply, "apply", false, "Remove what was found instead of only listing it")
+	cmd.Flags().BoolVar(&force, "force", false, "Discard local changes in worktrees without a backup")
+
+	root.AddCommand(cmd)
+}
+
+func runGC(ctx context.Context, p *project.Project, apply, force bool) error {
+	fmt.Println("[*] Looking for garbage...")
+
+	found, err := findStateGarbage(p, force)
+	if err != nil {
+		return err
+	}
+
+	dockerGarbage, err := findDockerGarbage(ctx, p)
+	if err != nil {
+		// The files are worth cleaning even when Docker is not running
+		fmt.Printf("Skipping images and volumes: %v\n", err)
+	}
+
+	found = append(found, dockerGarbage...)
+
+	if len(found) == 0 {
+		fmt.Println("Nothing to remove")
+		return nil
+	}
+
+	t := table.New("Kind", "Name", "Result")
+	t.SortBy([]table.SortBy{{Name: "Kind", Mode: table.Asc}, {Name: "Name", Mode: table.Asc}})
+
+	failed := 0
+	for _, g := range found {
+		if !apply {
+			t.AppendRow(g.kind, g.name, "would be removed")
+			continue
+		}
+
+		if err := g.remove(ctx); err != nil {
+			failed++
+			t.AppendRow(g.kind, g.name, "failed: "+err.Error())
+			continue
+		}
+
+		t.AppendRow(g.kind, g.name, "removed")
+	}
+
+	fmt.Println("")
+	t.Render()
+
+	if !apply {
+		fmt.Println("\nRun 'devbox gc --apply' to remove them")
+		return nil
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to remove %d of %d items", failed, len(found))
+	}
+
+	return nil
+}
+
+// findStateGarbage finds the state entries and the source directories the manifest doesn't need anymore. The state
+// is fixed first: removing the mounts of a worktree makes the worktree unused, and worktrees must go before the
+// source repositories they belong to.
+func findStateGarbage(p *project.Project, force bool) ([]garbage, error) {
+	var found []garbage
+
+	invalidMounts := p.UnknownMounts()
+	for mountPath, localPath := range p.DanglingMounts() {
+		invalidMounts[mountPath] = localPath
+	}
+
+	for _, mountPath := range sortedKeys(invalidMounts) {
+		found = append(found, garbage{kind: "mount", name: mountPath, remove: func(ctx context.Context) error {
+			delete(p.LocalMounts, mountPath)
+			return p.SaveState()
+		}})
+	}
+
+	for _, name := range p.UnknownOverrides() {
+		found = append(found, garbage{kind: "checkout", name: name, remove: func(ctx context.Context) error {
+			return p.ResetSource(name)
+		}})
+	}
+
+	worktrees := unusedWorktrees(p, invalidMounts)
+	for _, dir := range sortedKeys(worktrees) {
+		found = append(found, garbage{kind: "worktree", name: dir, remove: func(ctx context.Context) error {
+			backup, err := removeWorktree(ctx, p, dir, worktrees[dir], force)
+			if err != nil {
+				return err
+			}
+
+			if backup.dir != "" {
+				fmt.Printf("Local changes in worktree %s were saved to %s\n", dir, backup.dir)
+			}
+
+			return p.SaveState()
+		}})
+	}
+
+	orphaned, err := p.OrphanedSources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find orphaned sources: %w", err)
+	}
+
+	for _, dir := range orphaned {
+		name, _ := filepath.Rel(p.WorkingDir, dir)
+		found = append(found, garbage{kind: "source", name: "./" + name, remove: func(ctx context.Context) error {
+			return os.RemoveAll(dir)
+		}})
+	}
+
+	return found, nil
+}
+
+// unusedWorktrees returns the worktrees that no mount uses once the invalid mounts are gone, with their repositories.
+func unusedWorktrees(p *project.Project, invalidMounts map[string]string) map[string]string {
+	unused := map[string]string{}
+	for dir, repo := range p.Worktrees {
+		used := false
+		for mountPath, localPath := range p.LocalMounts {
+			if _, ok := invalidMounts[mountPath]; ok {
+				continue
+			}
+
+			if localPath == dir || strings.HasPrefix(localPath, dir+string(filepath.Separator)) {
+				used = true
+				break
+			}
+		}
+
+		if !used {
+			unused[dir] = repo
+		}
+	}
+
+	return unused
+}
+
+// findDockerGarbage finds the volumes and the images labeled with the project that no service uses.
+func findDockerGarbage(ctx context.Context, p *project.Project) ([]garbage, error) {
+	filters := make(client.Filters).Add("label", project.ProjectLabel+"="+p.Name)
+
+	volumes, err := dockerClient.VolumeList(ctx, client.VolumeListOptions{Filters: filters})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+
+	images, err := dockerClient.ImageList(ctx, client.ImageListOptions{Filters: filters})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+
+	var found []garbage
+
+	for _, name := range unusedVolumes(p, volumes.Items) {
+		found = append(found, garbage{kind: "volume", name: name, remove: func(ctx context.Context) error {
+			_, err := dockerClient.VolumeRemove(ctx, name, client.VolumeRemoveOptions{})
+			return err
+		}})
+	}
+
+	for _, img := range unusedImages(p, images.Items) {
+		found = append(found, garbage{kind: "image", name: imageName(img), remove: func(ctx context.Context) error {
+			_, err := dockerClient.ImageRemove(ctx, img.ID, client.ImageRemoveOptions{PruneChildren: true})
+			return err
+		}})
+	}
+
+	return found, nil
+}
+
+// unusedVolumes returns the names of the volumes of the project that the manifest doesn't declare anymore.
+func unusedVolumes(p *project.Project, volumes []volume.Volume) []string {
+	var unused []string
+	for _, v := range volumes {
+		if _, ok := p.Volumes[v.Labels[api.VolumeLabel]]; !ok {
+			unused = append(unused, v.Name)
+		}
+	}
+
+	slices.Sort(unused)
+
+	return unused
+}
+
+// unusedImages returns the images built by the project that no service is tagged with, older builds that lost
+// their tag included.
+func unusedImages(p *project.Project, images []image.Summary) []image.Summary {
+	used := map[string]bool{}
+	for _, svc := range p.Services {
+		name := api.GetImageNameOrDefault(svc, p.Name)
+		used[name] = true
+
+		if !strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+			used[name+":latest"] = true
+		}
+	}
+
+	var unused []image.Summary
+	for _, img := range images {
+		if !slices.ContainsFunc(img.RepoTags, func(tag string) bool { return used[tag] }) {
+			unused = append(unused, img)
+		}
+	}
+
+	return unused
+}
+
+func imageName(img image.Summary) string {
+	for _, tag := range img.RepoTags {
+		if tag != "<none>:<none>" {
+			return tag
+		}
+	}
+
+	return shortHash(strings.TrimPrefix(img.ID, "sha256:"))
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/image"
+	"github.com/moby/moby/api/types/volume"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pilat/devbox/internal/project"
+)
+
+func TestUnusedVolumes(t *testing.T) {
+	p := &project.Project{Project: &types.Project{
+		Name:    "shop",
+		Volumes: types.Volumes{"db": {}},
+	}}
+
+	got := unusedVolumes(p, []volume.Volume{
+		{Name: "shop_db", Labels: map[string]string{"com.docker.compose.volume": "db"}},
+		{Name: "shop_cache", Labels: map[string]string{"com.docker.compose.volume": "cache"}},
+		{Name: "shop_anonymous"},
+	})
+
+	assert.Equal(t, []string{"shop_anonymous", "shop_cache"}, got)
+}
+
+func TestUnusedImages(t *testing.T) {
+	p := &project.Project{Project: &types.Project{
+		Name: "shop",
+		Services: types.Services{
+			"api":    {Name: "api"},
+			"web":    {Name: "web", Image: "registry:5000/web"},
+			"worker": {Name: "worker", Image: "shop/worker:dev"},
+		},
+	}}
+
+	tests := []struct {
+		name   string
+		image  image.Summary
+		unused bool
+	}{
+		{"default name", image.Summary{ID: "1", RepoTags: []string{"shop-api:latest"}}, false},
+		{"registry with port", image.Summary{ID: "2", RepoTags: []string{"registry:5000/web:latest"}}, false},
+		{"image with tag", image.Summary{ID: "3", RepoTags: []string{"shop/worker:dev"}}, false},
+		{"removed service", image.Summary{ID: "4", RepoTags: []string{"shop-billing:latest"}}, true},
+		{"other tag", image.Summary{ID: "5", RepoTags: []string{"shop/worker:old"}}, true},
+		{"untagged", image.Summary{ID: "6"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unusedImages(p, []image.Summary{tt.image})
+			assert.Equal(t, tt.unused, len(got) == 1)
+		})
+	}
+}
+
+func TestUnusedWorktrees(t *testing.T) {
+	p := &project.Project{
+		Worktrees: map[string]string{
+			"/wt/api/fix":     "/src/api",
+			"/wt/billing/fix": "/src/billing",
+			"/wt/web/old":     "/src/web",
+		},
+		LocalMounts: map[string]string{
+			"./sources/api":     "/wt/api/fix/app",
+			"./sources/billing": "/wt/billing/fix",
+		},
+	}
+
+	got := unusedWorktrees(p, map[string]string{"./sources/billing": "/wt/billing/fix"})
+
+	assert.Equal(t, map[string]string{
+		"/wt/billing/fix": "/src/billing",
+		"/wt/web/old":     "/src/web",
+	}, got)
+}
+
+func TestImageName(t *testing.T) {
+	tagged := image.Summary{ID: "sha256:0123", RepoTags: []string{"<none>:<none>", "shop-api:latest"}}
+	assert.Equal(t, "shop-api:latest", imageName(tagged))
+	assert.Equal(t, shortHash("0123456789abcdef"), imageName(image.Summary{ID: "sha256:0123456789abcdef"}))
+}
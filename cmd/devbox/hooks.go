@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pilat/devbox/internal/project"
+)
+
+// runHooks executes the hooks attached to the event in x-devbox-hooks, if any.
+func runHooks(ctx context.Context, p *project.Project, event string) error {
+	plan, err := p.HookPlan(event)
+	if err != nil {
+		return fmt.Errorf("failed to plan hooks: %w", err)
+	}
+
+	if len(plan) == 0 {
+		return nil
+	}
+
+	fmt.Printf("[*] Running %s hooks...\n", event)
+
+	if err := runPlan(ctx, p, plan, false); err != nil {
+		return fmt.Errorf("%s hook failed: %w", event, err)
+	}
+
+	fmt.Println("")
+
+	return nil
+}
@@ -9,8 +9,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/pilat/devbox/internal/app"
-	"github.com/pilat/devbox/internal/git"
 	"github.com/pilat/devbox/internal/project"
+	"github.com/pilat/devbox/internal/source"
 	"github.com/pilat/devbox/internal/table"
 )
 
@@ -43,29 +43,31 @@ func init() {
 }
 
 func runInfo(ctx context.Context, p *project.Project) error {
-	sourcesTable := table.New("Name", "Message", "Author", "Date")
+	sourcesTable := table.New("Name", "Ref", "Message", "Author", "Date")
 	sourcesTable.SortBy([]table.SortBy{
 		{Name: "Message", Mode: table.Asc},
 		{Name: "Name", Mode: table.Asc},
 	})
 
-	for name, source := range p.Sources {
-		repoDir := filepath.Join(p.WorkingDir, app.SourcesDir, name)
+	for name, src := range p.Sources {
+		backend, err := source.New(src, source.Options{Dir: filepath.Join(p.WorkingDir, app.SourcesDir, name)})
+		if err != nil {
+			return fmt.Errorf("failed to get source %s: %w", name, err)
+		}
 
-		g := git.New(repoDir)
-		info, err := g.GetInfo(ctx)
+		info, err := backend.GetInfo(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to get git info for %s: %w", name, err)
+			return fmt.Errorf("failed to get info for %s: %w", name, err)
 		}
 
 		name := name
 		nameToDisplay := name
-		additionalInfo := strings.Join(source.SparseCheckout, ", ")
+		additionalInfo := strings.Join(src.SparseCheckout, ", ")
 		if additionalInfo != "" {
 			nameToDisplay = fmt.Sprintf("%s (%s)", nameToDisplay, additionalInfo)
 		}
 
-		sourcesTable.AppendRow(nameToDisplay, info.Message, info.Author, info.Date)
+		sourcesTable.AppendRow(nameToDisplay, formatSyncedRef(p, name), info.Message, info.Author, info.Date)
 	}
 
 	mountsTable := table.New("Mount path", "Local path")
@@ -87,6 +89,12 @@ func runInfo(ctx context.Context, p *project.Project) error {
 		mountsTable.Render()
 	}
 
+	if overrides := formatSourceOverrides(p); overrides != "" {
+		fmt.Println("")
+		fmt.Println("Sources checked out at other refs than configured:", overrides)
+		fmt.Println("Run 'devbox sources reset <source>' to return to the configured ref")
+	}
+
 	if len(p.Sources) == 0 && len(p.LocalMounts) == 0 {
 		fmt.Println("Project has no services or mounts")
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -12,9 +13,11 @@ import (
 func init() {
 	var name string
 	var branch string
+	var path string
+	var link bool
 
 	cmd := &cobra.Command{
-		Use:   "init <git-source>",
+		Use:   "init <git-source | directory>",
 		Short: "Initialize devbox project",
 		Long:  "That command will clone devbox project from git to your ~/.devbox directory and will keep it up to date",
 		PreRun: func(cmd *cobra.Command, args []string) {
@@ -24,13 +27,20 @@ func init() {
 			}
 		},
 		RunE: runWrapper(func(ctx context.Context, cmd *cobra.Command, args []string) error {
-			gitURL := args[0]
+			source := strings.TrimSuffix(args[0], "/")
+			if isLocalDir(source) {
+				if dir, err := filepath.Abs(source); err == nil {
+					source = dir // a name can be guessed even from "."
+				}
+			}
 
-			if name == "" {
-				name = guessName(gitURL)
+			if name == "" && path != "" {
+				name = guessName(strings.TrimSuffix(path, "/"))
+			} else if name == "" {
+				name = guessName(source)
 			}
 
-			if err := runInit(ctx, name, gitURL, branch); err != nil {
+			if err := runInit(ctx, name, source, branch, path, link); err != nil {
 				return fmt.Errorf("failed to initialize project: %w", err)
 			}
 
@@ -40,13 +50,34 @@ func init() {
 
 	cmd.Flags().StringVarP(&name, "name", "n", "", "Project name")
 	cmd.Flags().StringVarP(&branch, "branch", "b", "", "Branch to clone")
+	cmd.Flags().StringVarP(&path, "path", "p", "", "Directory of the manifest inside the repository")
+	cmd.Flags().BoolVar(&link, "link", false, "Use the local directory as it is instead of cloning it")
+
+	cmd.MarkFlagsMutuallyExclusive("link", "branch")
 
 	root.AddCommand(cmd)
 }
 
-func runInit(ctx context.Context, name, gitURL, branch string) error {
+func runInit(ctx context.Context, name, source, branch, path string, link bool) error {
+	local := isLocalDir(source)
+	if link && !local {
+		return fmt.Errorf("directory %s not found, only a local directory can be linked", source)
+	}
+
 	fmt.Println("[*] Initializing project...")
-	if err := mgr.Init(ctx, name, gitURL, branch); err != nil {
+
+	var err error
+	switch {
+	case link:
+		err = mgr.Link(ctx, name, filepath.Join(source, path))
+	case local:
+		// Cloned like any other repo, updates get what is committed there
+		err = mgr.Init(ctx, name, "file://"+filepath.ToSlash(source), branch, path)
+	default:
+		err = mgr.Init(ctx, name, source, branch, path)
+	}
+
+	if err != nil {
 		return fmt.Errorf("failed to init project: %w", err)
 	}
 
@@ -60,6 +91,17 @@ func runInit(ctx context.Context, name, gitURL, branch string) error {
 	return nil
 }
 
+// isLocalDir tells a directory on disk from a git URL.
+func isLocalDir(source string) bool {
+	if strings.Contains(source, "://") {
+		return false
+	}
+
+	info, err := os.Stat(source)
+
+	return err == nil && info.IsDir()
+}
+
 func guessName(source string) string {
 	elems := strings.Split(source, "/")
 	name := elems[len(elems)-1]
@@ -10,6 +10,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/pilat/devbox/internal/project"
+	"github.com/pilat/devbox/internal/settings"
 )
 
 func init() {
@@ -54,7 +55,7 @@ func runLogs(ctx context.Context, p *project.Project, services []string) error {
 	opts := project.LogOptions{
 		Project:  p.Project,
 		Services: services,
-		Tail:     "500",
+		Tail:     settings.LogsTail,
 		Follow:   true,
 	}
 
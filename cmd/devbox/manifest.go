@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pilat/devbox/internal/app"
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/project"
+)
+
+// manifestTarget tells where an update moves the manifest repository.
+type manifestTarget struct {
+	to       string // hold the manifest at this revision
+	rollback bool   // hold the manifest at the revision before the current one
+	follow   bool   // follow the branch again when the manifest is held
+	preview  bool   // only describe the move, nothing is changed
+}
+
+// updateManifest moves the manifest repository to the target and records the move in the manifest state. By default
+// the manifest is reset to the tracked branch of the remote, so local changes and diverged commits don't get in the
+// way. A held manifest is only moved by an explicit target.
+func updateManifest(
+	ctx context.Context, g git.Service, workingDir, name string, target manifestTarget,
+) (*changelogEntry, error) {
+	// The user edits a linked manifest in place, resetting it would throw their work away
+	if dir, linked := project.LinkTarget(workingDir); linked {
+		if target.to != "" || target.rollback {
+			return nil, fmt.Errorf("project is linked to %s, its manifest revisions are managed there", dir)
+		}
+
+		fmt.Printf("Project is linked to %s, its manifest is used as it is\n", dir)
+
+		return &changelogEntry{Name: name, Commits: []string{}}, nil
+	}
+
+	state, err := project.LoadManifestState(workingDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest state: %w", err)
+	}
+
+	before, err := g.GetInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git info: %w", err)
+	}
+
+	if state.Held != "" && target.to == "" && !target.rollback && !target.follow {
+		fmt.Printf("Manifest is held at %s, run 'devbox update' to follow branch %s again\n",
+			shortHash(state.Held), state.Branch)
+
+		return &changelogEntry{Name: name, From: before.Hash, To: before.Hash, Commits: []string{}}, nil
+	}
+
+	// The reset would throw away a lock file from 'devbox sources lock' that is not committed yet
+	if !target.preview {
+		dirty, err := g.HasChanges(ctx, app.LockFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check lock file: %w", err)
+		}
+
+		if dirty && (target.to != "" || target.rollback) {
+			return nil, fmt.Errorf("%s has uncommitted changes, commit it to the project repository first", app.LockFile)
+		} else if dirty {
+			fmt.Printf("%s has uncommitted changes, the manifest is kept at %s until it's committed\n",
+				app.LockFile, shortHash(before.Hash))
+
+			return &changelogEntry{Name: name, From: before.Hash, To: before.Hash, Commits: []string{}}, nil
+		}
+	}
+
+	if state.Branch == "" {
+		state.Branch, err = g.GetTrackedBranch(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tracked branch: %w", err)
+		}
+	}
+
+	// Rolling back needs nothing from the remote, the previous revisions are local
+	if !target.rollback {
+		if err := g.Fetch(ctx); err != nil {
+			return nil, fmt.Errorf("failed to fetch manifest: %w", err)
+		}
+	}
+
+	state.Record(project.ManifestRevision{Commit: before.Hash, Subject: before.Message, Date: time.Now()})
+
+	branch, rev := "", ""
+	switch {
+	case target.rollback:
+		prev, err := state.Rollback()
+		if errors.Is(err, project.ErrNoPreviousManifest) {
+			return nil, errors.New("there is no previous manifest revision to roll back to")
+		}
+
+		rev = prev.Commit
+	case target.to != "":
+		rev = target.to
+	default:
+		branch, rev = state.Branch, "refs/remotes/origin/"+state.Branch
+	}
+
+	to, err := g.ResolveRevision(ctx, rev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve manifest revision: %w", err)
+	}
+
+	entry, err := readChangelog(ctx, g, name, before.Hash, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.preview {
+		return &entry, nil
+	}
+
+	// A held manifest is detached at the resolved commit, so it can't move with the ref later
+	if branch == "" {
+		rev = to
+	}
+
+	if err := g.ResetTo(ctx, branch, rev); err != nil {
+		return nil, fmt.Errorf("failed to reset manifest: %w", err)
+	}
+
+	after, err := g.GetInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git info: %w", err)
+	}
+
+	state.Record(project.ManifestRevision{Commit: after.Hash, Subject: after.Message, Date: time.Now()})
+
+	state.Held = ""
+	if branch == "" {
+		state.Held = after.Hash
+		fmt.Printf("Manifest is held at %s %s, run 'devbox update' to follow branch %s again\n",
+			shortHash(after.Hash), after.Message, state.Branch)
+	}
+
+	if err := project.SaveManifestState(workingDir, state); err != nil {
+		return nil, fmt.Errorf("failed to save manifest state: %w", err)
+	}
+
+	return &entry, nil
+}
+
+func shortHash(hash string) string {
+	return hash[:min(len(hash), 7)]
+}
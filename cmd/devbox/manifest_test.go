@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/project"
+)
+
+func TestUpdateManifest(t *testing.T) {
+	history := []project.ManifestRevision{{Commit: "aaaa"}, {Commit: "bbbb"}}
+
+	tests := []struct {
+		name        string
+		state       *project.ManifestState
+		target      manifestTarget
+		setupMock   func(g *git.MockService)
+		wantTo      string
+		wantHeld    string
+		wantHistory []string
+		wantErr     string
+	}{
+		{
+			name:   "follows the tracked branch",
+			target: manifestTarget{follow: true},
+			setupMock: func(g *git.MockService) {
+				g.EXPECT().GetTrackedBranch(mock.Anything).Return("main", nil)
+				g.EXPECT().Fetch(mock.Anything).Return(nil)
+				g.EXPECT().ResolveRevision(mock.Anything, "refs/remotes/origin/main").Return("cccc", nil)
+				g.EXPECT().ResetTo(mock.Anything, "main", "refs/remotes/origin/main").Return(nil)
+			},
+			wantTo:      "cccc",
+			wantHistory: []string{"bbbb", "cccc"},
+		},
+		{
+			name:   "keeps held manifest",
+			state:  &project.ManifestState{Branch: "main", Held: "bbbb", History: history},
+			target: manifestTarget{},
+			wantTo: "bbbb", wantHeld: "bbbb", wantHistory: []string{"aaaa", "bbbb"},
+		},
+		{
+			name:   "resumes following held manifest",
+			state:  &project.ManifestState{Branch: "main", Held: "bbbb", History: history},
+			target: manifestTarget{follow: true},
+			setupMock: func(g *git.MockService) {
+				g.EXPECT().Fetch(mock.Anything).Return(nil)
+				g.EXPECT().ResolveRevision(mock.Anything, "refs/remotes/origin/main").Return("cccc", nil)
+				g.EXPECT().ResetTo(mock.Anything, "main", "refs/remotes/origin/main").Return(nil)
+			},
+			wantTo:      "cccc",
+			wantHistory: []string{"aaaa", "bbbb", "cccc"},
+		},
+		{
+			name:   "holds at revision",
+			state:  &project.ManifestState{Branch: "main"},
+			target: manifestTarget{to: "v1.0"},
+			setupMock: func(g *git.MockService) {
+				g.EXPECT().Fetch(mock.Anything).Return(nil)
+				g.EXPECT().ResolveRevision(mock.Anything, "v1.0").Return("aaaa", nil)
+				g.EXPECT().ResetTo(mock.Anything, "", "aaaa").Return(nil)
+			},
+			wantTo: "aaaa", wantHeld: "aaaa", wantHistory: []string{"bbbb", "aaaa"},
+		},
+		{
+			name:   "rolls back",
+			state:  &project.ManifestState{Branch: "main", History: history},
+			target: manifestTarget{rollback: true},
+			setupMock: func(g *git.MockService) {
+				g.EXPECT().ResolveRevision(mock.Anything, "aaaa").Return("aaaa", nil)
+				g.EXPECT().ResetTo(mock.Anything, "", "aaaa").Return(nil)
+			},
+			wantTo: "aaaa", wantHeld: "aaaa", wantHistory: []string{"aaaa"},
+		},
+		{
+			name:    "nothing to roll back to",
+			state:   &project.ManifestState{Branch: "main"},
+			target:  manifestTarget{rollback: true},
+			wantErr: "no previous manifest revision",
+		},
+		{
+			name:   "keeps manifest with uncommitted lock file",
+			state:  &project.ManifestState{Branch: "main", History: history},
+			target: manifestTarget{follow: true},
+			setupMock: func(g *git.MockService) {
+				g.EXPECT().HasChanges(mock.Anything, "devbox.lock").Return(true, nil)
+			},
+			wantTo:      "bbbb",
+			wantHistory: []string{"aaaa", "bbbb"},
+		},
+		{
+			name:   "refuses to roll back with uncommitted lock file",
+			state:  &project.ManifestState{Branch: "main", History: history},
+			target: manifestTarget{rollback: true},
+			setupMock: func(g *git.MockService) {
+				g.EXPECT().HasChanges(mock.Anything, "devbox.lock").Return(true, nil)
+			},
+			wantErr: "devbox.lock has uncommitted changes",
+		},
+		{
+			name:   "preview changes nothing",
+			state:  &project.ManifestState{Branch: "main", History: history},
+			target: manifestTarget{follow: true, preview: true},
+			setupMock: func(g *git.MockService) {
+				g.EXPECT().Fetch(mock.Anything).Return(nil)
+				g.EXPECT().ResolveRevision(mock.Anything, "refs/remotes/origin/main").Return("cccc", nil)
+			},
+			wantTo:      "cccc",
+			wantHistory: []string{"aaaa", "bbbb"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.state != nil {
+				require.NoError(t, project.SaveManifestState(dir, tt.state))
+			}
+
+			g := git.NewMockService(t)
+			g.EXPECT().GetInfo(mock.Anything).Return(&git.CommitInfo{Hash: "bbbb"}, nil).Once()
+			if tt.setupMock != nil {
+				tt.setupMock(g)
+			}
+			g.EXPECT().HasChanges(mock.Anything, "devbox.lock").Return(false, nil).Maybe()
+
+			if tt.wantTo != "" && tt.wantTo != "bbbb" {
+				g.EXPECT().GetChangelog(mock.Anything, "bbbb", tt.wantTo).Return(&git.Changelog{Files: 1}, nil)
+				if !tt.target.preview {
+					g.EXPECT().GetInfo(mock.Anything).Return(&git.CommitInfo{Hash: tt.wantTo}, nil).Once()
+				}
+			}
+
+			got, err := updateManifest(context.Background(), g, dir, "demo", tt.target)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "bbbb", got.From)
+			assert.Equal(t, tt.wantTo, got.To)
+
+			state, err := project.LoadManifestState(dir)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantHeld, state.Held)
+
+			commits := []string{}
+			for _, rev := range state.History {
+				commits = append(commits, rev.Commit)
+			}
+
+			assert.Equal(t, tt.wantHistory, commits)
+		})
+	}
+}
+
+func TestUpdateManifest_Linked(t *testing.T) {
+	workingDir := filepath.Join(t.TempDir(), "myproject")
+	require.NoError(t, os.Symlink(t.TempDir(), workingDir))
+
+	g := git.NewMockService(t)
+
+	got, err := updateManifest(context.Background(), g, workingDir, "myproject", manifestTarget{follow: true})
+	require.NoError(t, err)
+	assert.False(t, got.isChanged())
+
+	_, err = updateManifest(context.Background(), g, workingDir, "myproject", manifestTarget{rollback: true})
+	require.ErrorContains(t, err, "project is linked to")
+}
+
+func TestUpdateManifest_KeepsUncommittedLock(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	runGit := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_CONFIG_GLOBAL=/dev/null",
+			"GIT_CONFIG_SYSTEM=/dev/null",
+			"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+	}
+
+	origin := t.TempDir()
+	runGit(origin, "init", "-q", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(origin, "docker-compose.yml"), []byte("services: {}\n"), 0o644))
+	runGit(origin, "add", ".")
+	runGit(origin, "commit", "-q", "-m", "init")
+
+	workingDir := filepath.Join(t.TempDir(), "demo")
+	runGit(origin, "clone", "-q", origin, workingDir)
+
+	// What 'devbox sources lock' does, then 'devbox update --frozen' updates the manifest and loads the lock
+	p := &project.Project{Project: &types.Project{WorkingDir: workingDir}}
+	require.NoError(t, p.SaveLock(&project.LockFile{Sources: map[string]project.LockedSource{
+		"api": {URL: "https://example.com/api.git", Branch: "main", Commit: "3f9a1c7e"},
+	}}))
+
+	_, err := updateManifest(context.Background(), git.New(workingDir), workingDir, "demo", manifestTarget{})
+	require.NoError(t, err)
+
+	lock, err := p.LoadLock()
+	require.NoError(t, err)
+	assert.Equal(t, "3f9a1c7e", lock.Sources["api"].Commit)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -13,6 +14,7 @@ import (
 func init() {
 	var sourceName string
 	var targetPath string
+	var worktreeBranch string
 
 	cmd := &cobra.Command{
 		Use:   "mount",
@@ -46,6 +48,10 @@ func init() {
 			},
 		),
 		RunE: runWrapper(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			if targetPath != "" && worktreeBranch != "" {
+				return errors.New("--path and --worktree can't be used together")
+			}
+
 			p, err := mgr.AutodetectProject(ctx, projectName)
 			if err != nil {
 				return fmt.Errorf("failed to detect project: %w", err)
@@ -61,7 +67,19 @@ func init() {
 				mountPath = result.LocalPath
 			}
 
+			if worktreeBranch != "" {
+				mountPath, err = runAddWorktree(ctx, p, result.Sources, result.LocalPath, worktreeBranch)
+				if err != nil {
+					return fmt.Errorf("failed to add worktree: %w", err)
+				}
+			}
+
 			if err := runMount(ctx, p, result.Sources, mountPath); err != nil {
+				// The worktree created for the mount is of no use without it, if it stays it's in the state
+				if worktreeBranch != "" {
+					_ = runRemoveWorktrees(ctx, p, false)
+				}
+
 				return fmt.Errorf("failed to mount source code: %w", err)
 			}
 
@@ -69,6 +87,10 @@ func init() {
 				return fmt.Errorf("failed to restart services: %w", err)
 			}
 
+			if err := runHooks(ctx, p, project.HookPostMount); err != nil {
+				return fmt.Errorf("failed to run hooks: %w", err)
+			}
+
 			if err := runInfo(ctx, p); err != nil {
 				return fmt.Errorf("failed to get project info: %w", err)
 			}
@@ -79,6 +101,8 @@ func init() {
 
 	cmd.PersistentFlags().StringVarP(&sourceName, "source", "s", "", "Source name")
 	cmd.PersistentFlags().StringVarP(&targetPath, "path", "p", "", "Path to mount")
+	cmd.PersistentFlags().StringVarP(
+		&worktreeBranch, "worktree", "w", "", "Mount a new worktree with the branch checked out")
 
 	_ = cmd.RegisterFlagCompletionFunc(
 		"source",
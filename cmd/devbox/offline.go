@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/pilat/devbox/internal/app"
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/project"
+	"github.com/pilat/devbox/internal/source"
+	"github.com/pilat/devbox/internal/table"
+)
+
+// offline makes up, restart and run use the manifest repository and the sources as they are on disk, nothing is
+// pulled or fetched. It is set by --offline or when a remote turns out to be unreachable.
+var offline bool
+
+// fallbackOffline switches the rest of the command to offline mode when err tells that a remote is unreachable,
+// it returns false for any other error.
+func fallbackOffline(err error) bool {
+	if !errors.Is(err, git.ErrRemoteUnreachable) {
+		return false
+	}
+
+	fmt.Println("")
+	fmt.Println("Remote is unreachable, continuing offline with the current checkouts")
+	fmt.Println(err)
+
+	offline = true
+
+	return true
+}
+
+// runOfflineReport warns how long ago the manifest repository and the sources were synced.
+func runOfflineReport(ctx context.Context, p *project.Project) error {
+	fmt.Println("[*] Working offline, sources are not updated...")
+
+	t := table.New("Name", "Last synced")
+	t.SortBy([]table.SortBy{{Name: "Name", Mode: table.Asc}})
+
+	now := time.Now()
+
+	fetched, err := git.New(p.WorkingDir).GetLastFetch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get last update of project: %w", err)
+	}
+
+	t.AppendRow(p.Name+" (manifest)", formatAge(now.Sub(fetched)))
+
+	for name, src := range p.Sources {
+		backend, err := source.New(src, source.Options{Dir: filepath.Join(p.WorkingDir, app.SourcesDir, name)})
+		if err != nil {
+			return fmt.Errorf("failed to get source %s: %w", name, err)
+		}
+
+		reader, ok := backend.(source.LastSyncReader)
+		if !ok {
+			continue
+		}
+
+		synced, err := reader.GetLastSync(ctx)
+		switch {
+		case errors.Is(err, source.ErrNotSynced):
+			t.AppendRow(name, "never, the source is missing")
+		case err != nil:
+			return fmt.Errorf("failed to get last sync of %s: %w", name, err)
+		default:
+			t.AppendRow(name, formatAge(now.Sub(synced)))
+		}
+	}
+
+	fmt.Println("")
+	t.Render()
+	fmt.Println("Run 'devbox update' to sync the sources once the remotes are reachable again")
+	fmt.Println("")
+
+	return nil
+}
+
+// formatAge describes a duration in the largest whole unit, e.g. "3 days ago".
+func formatAge(d time.Duration) string {
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+
+	for _, u := range units {
+		if n := int(d / u.size); n > 0 {
+			if n == 1 {
+				return fmt.Sprintf("1 %s ago", u.name)
+			}
+
+			return fmt.Sprintf("%d %ss ago", n, u.name)
+		}
+	}
+
+	return "just now"
+}
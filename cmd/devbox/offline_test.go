@@ -0,0 +1,148 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/compose-spec/compose-go/v2/types"
+
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/project"
+	"github.com/pilat/devbox/internal/source"
+)
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{age: 20 * time.Second, want: "just now"},
+		{age: time.Minute, want: "1 minute ago"},
+		{age: 3*time.Hour + 20*time.Minute, want: "3 hours ago"},
+		{age: 50 * time.Hour, want: "2 days ago"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatAge(tt.age))
+		})
+	}
+}
+
+func TestFallbackOffline(t *testing.T) {
+	t.Cleanup(func() { offline = false })
+
+	offline = false
+	assert.False(t, fallbackOffline(errors.New("authentication failed")))
+	assert.False(t, offline)
+
+	assert.True(t, fallbackOffline(fmt.Errorf("failed to pull: %w", git.ErrRemoteUnreachable)))
+	assert.True(t, offline)
+}
+
+func TestFallbackOffline_Archive(t *testing.T) {
+	t.Cleanup(func() { offline = false })
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(notFound.Close)
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		want bool
+	}{
+		{name: "host is down", url: down.URL + "/api.tar.gz", want: true},
+		{name: "host is unknown", url: "http://devbox.invalid/api.tar.gz", want: true},
+		{name: "archive is missing", url: notFound.URL + "/api.tar.gz", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offline = false
+
+			cfg := project.SourceConfig{Type: project.SourceTypeArchive, URL: tt.url}
+			backend, err := source.New(cfg, source.Options{Dir: filepath.Join(t.TempDir(), "sources", "api")})
+			require.NoError(t, err)
+
+			err = backend.Sync(t.Context())
+			require.Error(t, err)
+			assert.Equal(t, tt.want, fallbackOffline(err))
+			assert.Equal(t, tt.want, offline)
+		})
+	}
+}
+
+func TestSyncSources_UnreachableKeepsOtherSyncs(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	tests := []struct {
+		name        string
+		status      int
+		wantOffline bool
+	}{
+		{name: "other source is synced", status: http.StatusOK, wantOffline: true},
+		{name: "failure of other source is reported", status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { offline = false })
+
+			// Slow enough for the unreachable source to fail first
+			slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(200 * time.Millisecond)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write(tarGz(t, "main.go", "package main\n"))
+			}))
+			t.Cleanup(slow.Close)
+
+			p := &project.Project{
+				Project: &types.Project{WorkingDir: t.TempDir()},
+				Sources: project.SourceConfigs{
+					"api": {Type: project.SourceTypeArchive, URL: down.URL + "/api.tar.gz"},
+					"web": {Type: project.SourceTypeArchive, URL: slow.URL + "/web.tar.gz"},
+				},
+			}
+
+			noop := func(string) {}
+			_, err := syncSources(t.Context(), p, nil, false, noop, noop, noop)
+			require.Error(t, err)
+			assert.Equal(t, tt.wantOffline, fallbackOffline(err))
+
+			_, statErr := os.Stat(filepath.Join(p.WorkingDir, "sources", "web", "main.go"))
+			assert.Equal(t, tt.wantOffline, statErr == nil)
+		})
+	}
+}
+
+func tarGz(t *testing.T, name, content string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}))
+	_, err := tw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
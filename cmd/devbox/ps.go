@@ -106,6 +106,10 @@ func runPs(ctx context.Context, p *project.Project) error {
 
 			processTable.Render()
 
+			if overrides := formatSourceOverrides(p); overrides != "" {
+				fmt.Println("Sources checked out at other refs than configured:", overrides)
+			}
+
 			time.Sleep(250 * time.Millisecond)
 		}
 	}()
@@ -13,6 +13,7 @@ import (
 
 func init() {
 	var profiles []string
+	var force bool
 
 	cmd := &cobra.Command{
 		Use:               "restart",
@@ -26,8 +27,12 @@ func init() {
 				return fmt.Errorf("failed to detect project: %w", err)
 			}
 
-			if err := runProjectUpdate(ctx, p); err != nil {
-				return fmt.Errorf("failed to update project: %w", err)
+			var manifest *changelogEntry
+			if !offline {
+				manifest, err = runProjectUpdate(ctx, p, manifestTarget{})
+				if err != nil && !fallbackOffline(err) {
+					return fmt.Errorf("failed to update project: %w", err)
+				}
 			}
 
 			if err := runHostsUpdate(p, true, false); err != nil {
@@ -38,8 +43,24 @@ func init() {
 				return fmt.Errorf("failed to update certificates: %w", err)
 			}
 
-			if err := runSourcesUpdate(ctx, p); err != nil {
-				return fmt.Errorf("failed to update sources: %w", err)
+			var sources []changelogEntry
+			if !offline {
+				sources, err = runSourcesUpdate(ctx, p, syncOptions{force: force})
+				if err != nil && !fallbackOffline(err) {
+					return fmt.Errorf("failed to update sources: %w", err)
+				}
+			}
+
+			if offline {
+				if err := runOfflineReport(ctx, p); err != nil {
+					return fmt.Errorf("failed to report sources: %w", err)
+				}
+			} else {
+				printChangelog(newChangelog(manifest, sources))
+			}
+
+			if err := runHooks(ctx, p, project.HookPostSourceSync); err != nil {
+				return fmt.Errorf("failed to run hooks: %w", err)
 			}
 
 			if err := p.Reload(ctx, profiles); err != nil {
@@ -55,6 +76,7 @@ func init() {
 	}
 
 	cmd.PersistentFlags().StringSliceVarP(&profiles, "profile", "p", []string{}, "Profile to use")
+	cmd.Flags().BoolVar(&force, "force", false, "Discard local changes in sources without a backup")
 
 	_ = cmd.RegisterFlagCompletionFunc(
 		"profile",
@@ -121,7 +143,7 @@ func runRestart(ctx context.Context, p *project.Project, services []string, noDe
 		networksBackup := p.Networks
 		p.Networks = project.Networks{} // to avoid an attempt to remove a network
 
-		if err := runDown(ctx, p, false); err != nil {
+		if err := runDown(ctx, p, project.DownOptions{}); err != nil {
 			return err
 		}
 
@@ -2,13 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/docker/compose/v5/pkg/api"
 	"github.com/spf13/cobra"
 
 	"github.com/pilat/devbox/internal/project"
+	"github.com/pilat/devbox/internal/table"
 )
 
 func init() {
@@ -21,7 +26,7 @@ func newRunCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "run <scenario>",
 		Short: "Run scenario defined in devbox project",
-		Long:  "You can pass additional arguments to the scenario",
+		Long:  "You can pass parameters and additional arguments to the scenario, see 'devbox run <scenario> --help'",
 		Args:  cobra.MinimumNArgs(1),
 		ValidArgsFunction: validArgsWrapper(
 			func(ctx context.Context, cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -30,11 +35,15 @@ func newRunCmd() *cobra.Command {
 					return []string{}, cobra.ShellCompDirectiveNoFileComp
 				}
 
-				// If a scenario is already provided (or project is not detected), disallow further completions
-				if len(args) > 0 || p == nil {
+				if p == nil {
 					return []string{}, cobra.ShellCompDirectiveNoFileComp
 				}
 
+				// Once a scenario is provided, only its parameters can be completed
+				if len(args) > 0 {
+					return p.GetScenarioParameters(args[0], args[1:], toComplete), cobra.ShellCompDirectiveNoFileComp
+				}
+
 				return p.GetScenarios(toComplete), cobra.ShellCompDirectiveNoFileComp
 			},
 		),
@@ -46,6 +55,10 @@ func newRunCmd() *cobra.Command {
 
 			scenario, passthrough := splitScenarioArgs(args)
 
+			if isScenarioHelp(args) {
+				return runScenarioHelp(p, scenario)
+			}
+
 			if err := runRun(ctx, p, scenario, passthrough, noTty); err != nil {
 				return fmt.Errorf("failed to run scenario: %w", err)
 			}
@@ -74,53 +87,153 @@ func splitScenarioArgs(args []string) (scenario string, passthrough []string) {
 	return scenario, passthrough
 }
 
+// isScenarioHelp reports whether the help of the scenario itself is requested. Use "--" to forward
+// --help to the scenario command instead.
+func isScenarioHelp(args []string) bool {
+	return len(args) > 1 && (args[1] == "--help" || args[1] == "-h")
+}
+
+func runScenarioHelp(p *project.Project, name string) error {
+	scenario, ok := p.Scenarios[name]
+	if !ok {
+		return fmt.Errorf("scenario %q not found", name)
+	}
+
+	fmt.Printf("Usage: devbox run %s [--<parameter> <value> ...] [args...]\n", name)
+
+	if scenario.Description != "" {
+		fmt.Println("")
+		fmt.Println(scenario.Description)
+	}
+
+	if len(scenario.Parameters) == 0 {
+		fmt.Println("")
+		fmt.Println("Scenario has no parameters")
+
+		return nil
+	}
+
+	t := table.New("Name", "Type", "Default", "Choices", "Description")
+	t.Compact()
+
+	for _, paramName := range scenario.Parameters.Names() {
+		param := scenario.Parameters[paramName]
+
+		defaultValue := param.DefaultValue()
+		if param.Required && param.Default == nil {
+			defaultValue = "(required)"
+		}
+
+		t.AppendRow("--"+paramName, param.TypeName(), defaultValue, strings.Join(param.Choices, ", "), param.Description)
+	}
+
+	fmt.Println("")
+	fmt.Println(" Parameters:")
+	t.Render()
+
+	return nil
+}
+
 func runRun(ctx context.Context, p *project.Project, command string, args []string, noTtyFlag bool) error {
-	isRunning, err := isRunning(ctx, apiService, p)
+	plan, err := p.ScenarioPlan(command, args)
 	if err != nil {
-		return fmt.Errorf("failed to check if services are running: %w", err)
+		return fmt.Errorf("failed to plan scenario: %w", err)
 	}
 
-	if !isRunning {
-		return nil
+	// A plain single-command scenario behaves exactly like before: no progress output, no summary
+	if len(plan) == 1 {
+		return runScenarioStep(ctx, p, plan[0], noTtyFlag)
 	}
 
-	scenario, ok := p.Scenarios[command]
-	if !ok {
-		return fmt.Errorf("scenario %q not found", command)
+	return runPlan(ctx, p, plan, noTtyFlag)
+}
+
+// runPlan executes the steps in order and prints a per-step summary. Steps after a failed one are skipped
+// unless the failed step is allowed to continue on error.
+func runPlan(ctx context.Context, p *project.Project, plan []project.ScenarioPlanStep, noTtyFlag bool) error {
+	t := table.New("#", "Step", "Target", "Result", "Duration")
+	t.SortBy([]table.SortBy{{Name: "#", Mode: table.AscNumeric}})
+
+	var failed error
+	for i, step := range plan {
+		if failed != nil {
+			t.AppendRow(i+1, step.Name, stepTarget(step), "skipped", "-")
+			continue
+		}
+
+		fmt.Printf("[*] Step %d/%d: %s\n", i+1, len(plan), step.Name)
+
+		start := time.Now()
+		err := runScenarioStep(ctx, p, step, noTtyFlag)
+		duration := time.Since(start).Round(time.Millisecond).String()
+
+		switch {
+		case err == nil:
+			t.AppendRow(i+1, step.Name, stepTarget(step), "ok", duration)
+		case step.ContinueOnError:
+			t.AppendRow(i+1, step.Name, stepTarget(step), "failed (ignored): "+err.Error(), duration)
+		default:
+			t.AppendRow(i+1, step.Name, stepTarget(step), "failed: "+err.Error(), duration)
+			failed = fmt.Errorf("step %q failed: %w", step.Name, err)
+		}
 	}
 
-	commands := make([]string, 0, len(scenario.Command)+len(args))
-	commands = append(commands, scenario.Command...)
-	commands = append(commands, args...)
+	fmt.Println("")
+	t.Render()
+
+	return failed
+}
+
+func runScenarioStep(ctx context.Context, p *project.Project, step project.ScenarioPlanStep, noTtyFlag bool) error {
+	if step.Mode == project.ScenarioModeHost {
+		return runHostCommand(ctx, p, step.ScenarioStep)
+	}
 
 	interactive := true
-	if scenario.Interactive != nil {
-		interactive = *scenario.Interactive
+	if step.Interactive != nil {
+		interactive = *step.Interactive
 	}
 
 	var tty bool
 	switch {
 	case noTtyFlag:
 		tty = false
-	case scenario.Tty != nil:
-		tty = *scenario.Tty
+	case step.Tty != nil:
+		tty = *step.Tty
 	default:
 		tty = isTTYAvailable(os.Stdin)
 	}
 
 	opts := project.RunOptions{
-		Service:     scenario.Service,
+		Service:     step.Service,
 		Interactive: interactive,
 		Tty:         tty,
-		Command:     commands,
-		Entrypoint:  scenario.Entrypoint,
-		WorkingDir:  scenario.WorkingDir,
-		User:        scenario.User,
+		Command:     step.Command,
+		Entrypoint:  step.Entrypoint,
+		WorkingDir:  step.WorkingDir,
+		User:        step.User,
 	}
 
-	exitCode, err := apiService.Exec(ctx, p.Name, opts)
+	running, err := isServiceRunning(ctx, apiService, p, step.Service)
 	if err != nil {
-		return fmt.Errorf("failed to exec: %w", err)
+		return fmt.Errorf("failed to check if service is running: %w", err)
+	}
+
+	var exitCode int
+	switch {
+	case step.Mode == project.ScenarioModeRun || (step.Mode == project.ScenarioModeAuto && !running):
+		exitCode, err = runOneOffContainer(ctx, p, opts)
+		if err != nil {
+			return fmt.Errorf("failed to run one-off container: %w", err)
+		}
+	case running:
+		exitCode, err = apiService.Exec(ctx, p.Name, opts)
+		if err != nil {
+			return fmt.Errorf("failed to exec: %w", err)
+		}
+	default:
+		return fmt.Errorf("service %q is not running, start it with 'devbox up' or use mode %q",
+			step.Service, project.ScenarioModeRun)
 	}
 
 	if exitCode != 0 {
@@ -130,6 +243,95 @@ func runRun(ctx context.Context, p *project.Project, command string, args []stri
 	return nil
 }
 
+// runHostCommand runs the step command on the host with the project environment exported.
+func runHostCommand(ctx context.Context, p *project.Project, step project.ScenarioStep) error {
+	args := make([]string, 0, len(step.Entrypoint)+len(step.Command))
+	args = append(args, step.Entrypoint...)
+	args = append(args, step.Command...)
+
+	dir := p.HostWorkingDir(step)
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Env = p.HostEnvironment()
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("non-zero exit code: %d", exitErr.ExitCode())
+	} else if err != nil {
+		return fmt.Errorf("failed to run command: %w", err)
+	}
+
+	return nil
+}
+
+func stepTarget(step project.ScenarioPlanStep) string {
+	if step.Mode == project.ScenarioModeHost {
+		return "host"
+	}
+
+	return step.Service
+}
+
+// runOneOffContainer starts a fresh container of the service together with its dependencies. Missing
+// images are built or pulled, the container is removed when the command exits.
+func runOneOffContainer(ctx context.Context, p *project.Project, opts project.RunOptions) (int, error) {
+	sp, err := p.WithSelectedServices([]string{opts.Service})
+	if err != nil {
+		return 0, fmt.Errorf("failed to select service: %w", err)
+	}
+
+	if offline {
+		sp.DisablePulls()
+	}
+
+	opts.Project = sp.Project
+	opts.AutoRemove = true
+	opts.QuietPull = true
+	opts.Build = &project.BuildOptions{Quiet: true}
+
+	svc, err := newProgressCompose()
+	if err != nil {
+		return 0, err
+	}
+
+	exitCode, err := svc.RunOneOffContainer(ctx, sp.Project, opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to run container: %w", err)
+	}
+
+	return exitCode, nil
+}
+
+func isServiceRunning(ctx context.Context, a api.Compose, p *project.Project, service string) (bool, error) {
+	opts := project.PsOptions{
+		Project:  p.Project,
+		Services: []string{service},
+	}
+
+	containers, err := a.Ps(ctx, p.Name, opts)
+	if err != nil {
+		return false, fmt.Errorf("failed to get services: %w", err)
+	}
+
+	for _, container := range containers {
+		if container.Labels[project.ServiceLabel] == service &&
+			container.Labels[project.WorkingDirLabel] == p.WorkingDir {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 func isRunning(ctx context.Context, a api.Compose, p *project.Project) (bool, error) {
 	opts := project.PsOptions{
 		Project: p.Project,
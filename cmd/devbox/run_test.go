@@ -69,6 +69,7 @@ func TestRunCmdArgsContract(t *testing.T) {
 			wantArgs: []string{"e2e", "--", "--tag", "foo"},
 		},
 		{name: "scenario only", argv: []string{"e2e"}, wantArgs: []string{"e2e"}},
+		{name: "scenario help", argv: []string{"e2e", "--help"}, wantArgs: []string{"e2e", "--help"}},
 		{name: "no scenario", argv: []string{}, wantErr: true},
 	}
 
@@ -98,3 +99,25 @@ func TestRunCmdArgsContract(t *testing.T) {
 		})
 	}
 }
+
+func TestIsScenarioHelp(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{name: "long", args: []string{"e2e", "--help"}, want: true},
+		{name: "short", args: []string{"e2e", "-h"}, want: true},
+		{name: "scenario only", args: []string{"e2e"}, want: false},
+		{name: "forwarded after separator", args: []string{"e2e", "--", "--help"}, want: false},
+		{name: "not first", args: []string{"e2e", "--tag", "--help"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isScenarioHelp(tt.args); got != tt.want {
+				t.Errorf("isScenarioHelp(%v) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
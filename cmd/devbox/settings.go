@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/pilat/devbox/internal/settings"
+)
+
+var settingsCmd = &cobra.Command{
+	Use:   "settings",
+	Short: "Manage devbox settings",
+	Long:  "Provides commands to read and change the user settings of devbox, such as the data root and the limits",
+}
+
+func init() {
+	root.AddCommand(settingsCmd)
+}
+
+// suggestSettings completes the first argument with the setting names and their descriptions.
+func suggestSettings(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return []string{}, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := settings.Names()
+
+	completions := make([]string, 0, len(names))
+	for _, name := range names {
+		completions = append(completions, name+"\t"+settings.Describe(name))
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pilat/devbox/internal/settings"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:               "get <name>",
+		Short:             "Print a setting",
+		Long:              "That command prints the value of a setting in effect",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: suggestSettings,
+		RunE: runWrapper(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			value, _, err := settings.Get(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get setting: %w", err)
+			}
+
+			fmt.Println(value)
+
+			return nil
+		}),
+	}
+
+	settingsCmd.AddCommand(cmd)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pilat/devbox/internal/settings"
+	"github.com/pilat/devbox/internal/table"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List settings",
+		Long:  "That command lists all settings with their values in effect and where the values come from",
+		Args:  cobra.NoArgs,
+		RunE: runWrapper(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			if err := runSettingsList(); err != nil {
+				return fmt.Errorf("failed to list settings: %w", err)
+			}
+
+			return nil
+		}),
+	}
+
+	settingsCmd.AddCommand(cmd)
+}
+
+func runSettingsList() error {
+	path, err := settings.Path()
+	if err != nil {
+		return fmt.Errorf("failed to get settings file: %w", err)
+	}
+
+	t := table.New("Name", "Value", "Source")
+
+	for _, name := range settings.Names() {
+		value, source, err := settings.Get(name)
+		if err != nil {
+			return fmt.Errorf("failed to get setting: %w", err)
+		}
+
+		t.AppendRow(name, value, source)
+	}
+
+	fmt.Println("")
+	fmt.Println(" Settings:")
+	t.Render()
+	fmt.Println("Settings file:", path)
+
+	return nil
+}
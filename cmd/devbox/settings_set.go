@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pilat/devbox/internal/settings"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:               "set <name> <value>",
+		Short:             "Change a setting",
+		Long:              "That command writes a setting to the settings file, an empty value restores the default",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: suggestSettings,
+		RunE: runWrapper(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			path, err := settings.Path()
+			if err != nil {
+				return fmt.Errorf("failed to get settings file: %w", err)
+			}
+
+			if err := settings.Set(path, args[0], args[1]); err != nil {
+				return fmt.Errorf("failed to set setting: %w", err)
+			}
+
+			value, source, err := settings.Get(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get setting: %w", err)
+			}
+
+			fmt.Printf("%s is %s (%s)\n", args[0], value, source)
+
+			return nil
+		}),
+	}
+
+	settingsCmd.AddCommand(cmd)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pilat/devbox/internal/project"
+)
+
+var sourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "Manage project sources",
+	Long:  "Provides commands to manage sources defined in x-devbox-sources",
+}
+
+func init() {
+	root.AddCommand(sourcesCmd)
+}
+
+// formatSourceRef describes the ref a source tracks, e.g. "tag v1.2.0".
+func formatSourceRef(src project.SourceConfig) string {
+	switch src.SourceType() {
+	case project.SourceTypePath:
+		return "path " + src.Path
+	case project.SourceTypeArchive:
+		if src.Checksum == "" {
+			return "archive"
+		}
+
+		return "archive " + src.Checksum[:min(len(src.Checksum), 19)]
+	}
+
+	kind, name := src.Ref()
+	switch {
+	case kind == project.RefKindCommit && len(name) > 12:
+		name = name[:12]
+	case kind == project.RefKindBranch && name == "":
+		name = "(default)"
+	}
+
+	return kind + " " + name
+}
+
+// formatSyncedRef describes the ref a source is synced at, which is the configured ref unless it was overridden
+// with `devbox sources checkout`.
+func formatSyncedRef(p *project.Project, name string) string {
+	if ref, ok := p.SourceOverrides[name]; ok {
+		return ref + " (override)"
+	}
+
+	return formatSourceRef(p.Sources[name])
+}
+
+// formatSourceOverrides lists the overridden sources of the project as "name (ref)", sorted by name.
+func formatSourceOverrides(p *project.Project) string {
+	names := []string{}
+	for name := range p.SourceOverrides {
+		if _, ok := p.Sources[name]; ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	for i, name := range names {
+		names[i] = fmt.Sprintf("%s (%s)", name, p.SourceOverrides[name])
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// getSourceCompletions suggests source names that are not in args yet.
+func getSourceCompletions(p *project.Project, args []string, toComplete string) []string {
+	results := []string{}
+	for name := range p.Sources {
+		if slices.Contains(args, name) || !strings.HasPrefix(strings.ToLower(name), strings.ToLower(toComplete)) {
+			continue
+		}
+
+		results = append(results, name)
+	}
+
+	sort.Strings(results)
+
+	return results
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pilat/devbox/internal/app"
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/project"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "checkout <source> <ref>",
+		Short: "Sync a source at another branch or ref until it is reset",
+		Long: "That command makes the syncs check out a branch or a full ref, e.g. refs/pull/1/head, " +
+			"in the source instead of the configured one, until 'devbox sources reset'",
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: validArgsWrapper(
+			func(ctx context.Context, cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				if len(args) > 0 {
+					return []string{}, cobra.ShellCompDirectiveNoFileComp
+				}
+
+				p, err := mgr.AutodetectProject(ctx, projectName)
+				if err != nil {
+					return []string{}, cobra.ShellCompDirectiveNoFileComp
+				}
+
+				return getSourceCompletions(p, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+			},
+		),
+		RunE: runWrapper(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			p, err := mgr.AutodetectProject(ctx, projectName)
+			if err != nil {
+				return fmt.Errorf("failed to detect project: %w", err)
+			}
+
+			if err := runSourcesCheckout(ctx, p, args[0], args[1]); err != nil {
+				return fmt.Errorf("failed to check out source: %w", err)
+			}
+
+			return nil
+		}),
+	}
+
+	sourcesCmd.AddCommand(cmd)
+}
+
+func runSourcesCheckout(ctx context.Context, p *project.Project, name, ref string) error {
+	src, ok := p.Sources[name]
+	if !ok {
+		return fmt.Errorf("source %q not found", name)
+	}
+
+	fmt.Printf("[*] Looking up %s in %s...\n", ref, src.URL)
+
+	// Fail now rather than on the next sync, a ref without refs/ prefix is taken for a branch
+	remoteRef := ref
+	if !strings.HasPrefix(ref, "refs/") {
+		remoteRef = "refs/heads/" + ref
+	}
+
+	g := git.New(filepath.Join(p.WorkingDir, app.SourcesDir, name))
+	if _, err := g.ResolveRef(ctx, src.URL, remoteRef); err != nil {
+		return fmt.Errorf("failed to resolve ref: %w", err)
+	}
+
+	if err := p.CheckoutSource(name, ref); err != nil {
+		return fmt.Errorf("failed to save override: %w", err)
+	}
+
+	fmt.Printf("Source '%s' will be synced at %s by 'devbox up', 'devbox update' and 'devbox restart'\n", name, ref)
+	fmt.Printf("Run 'devbox sources reset %s' to return to %s\n", name, formatSourceRef(src))
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pilat/devbox/internal/app"
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/project"
+	"github.com/pilat/devbox/internal/table"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "lock [source...]",
+		Short: "Pin sources to the current commits of their branches or tags",
+		Long:  "That command resolves the branch or tag of every source and writes the commits to " + app.LockFile,
+		ValidArgsFunction: validArgsWrapper(
+			func(ctx context.Context, cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				p, err := mgr.AutodetectProject(ctx, projectName)
+				if err != nil {
+					return []string{}, cobra.ShellCompDirectiveNoFileComp
+				}
+
+				return getSourceCompletions(p, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+			},
+		),
+		RunE: runWrapper(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			p, err := mgr.AutodetectProject(ctx, projectName)
+			if err != nil {
+				return fmt.Errorf("failed to detect project: %w", err)
+			}
+
+			if err := runSourcesLock(ctx, p, args); err != nil {
+				return fmt.Errorf("failed to lock sources: %w", err)
+			}
+
+			return nil
+		}),
+	}
+
+	sourcesCmd.AddCommand(cmd)
+}
+
+func runSourcesLock(ctx context.Context, p *project.Project, names []string) error {
+	lock, err := p.LoadLock()
+	if errors.Is(err, project.ErrLockNotFound) {
+		lock = &project.LockFile{Sources: map[string]project.LockedSource{}}
+	} else if err != nil {
+		return fmt.Errorf("failed to load lock file: %w", err)
+	}
+
+	if len(names) == 0 {
+		// Full refresh: forget pins of sources that were removed from the manifest
+		lock.Sources = map[string]project.LockedSource{}
+		for name, src := range p.Sources {
+			if src.SourceType() == project.SourceTypeGit {
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	fmt.Println("[*] Resolving sources...")
+
+	t := table.New("Name", "Ref", "Commit")
+	for _, name := range names {
+		src, ok := p.Sources[name]
+		if !ok {
+			return fmt.Errorf("source %q not found", name)
+		}
+
+		if src.SourceType() != project.SourceTypeGit {
+			return fmt.Errorf("source %q is not a git source and can't be pinned", name)
+		}
+
+		commit := src.Commit
+		if commit == "" {
+			g := git.New(filepath.Join(p.WorkingDir, app.SourcesDir, name))
+			commit, err = g.ResolveRef(ctx, src.URL, src.RemoteRef())
+			if err != nil {
+				return fmt.Errorf("failed to resolve source %q: %w", name, err)
+			}
+		}
+
+		lock.Sources[name] = project.LockedSource{
+			URL:    src.URL,
+			Branch: src.Branch,
+			Tag:    src.Tag,
+			Commit: commit,
+		}
+
+		t.AppendRow(name, formatSourceRef(src), commit)
+	}
+
+	if err := p.SaveLock(lock); err != nil {
+		return fmt.Errorf("failed to save lock file: %w", err)
+	}
+
+	fmt.Println("")
+	t.Render()
+	fmt.Printf("\nCommit %s to the project repository to share the pins, the manifest is not updated until then\n",
+		app.LockFile)
+
+	return nil
+}
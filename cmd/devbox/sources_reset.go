@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pilat/devbox/internal/project"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "reset <source...>",
+		Short: "Return sources to their configured refs",
+		Long:  "That command removes the overrides made by 'devbox sources checkout'",
+		Args:  cobra.MinimumNArgs(1),
+		ValidArgsFunction: validArgsWrapper(
+			func(ctx context.Context, cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				p, err := mgr.AutodetectProject(ctx, projectName)
+				if err != nil {
+					return []string{}, cobra.ShellCompDirectiveNoFileComp
+				}
+
+				results := []string{}
+				for name := range p.SourceOverrides {
+					if !slices.Contains(args, name) && strings.HasPrefix(name, toComplete) {
+						results = append(results, name)
+					}
+				}
+
+				sort.Strings(results)
+
+				return results, cobra.ShellCompDirectiveNoFileComp
+			},
+		),
+		RunE: runWrapper(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			p, err := mgr.AutodetectProject(ctx, projectName)
+			if err != nil {
+				return fmt.Errorf("failed to detect project: %w", err)
+			}
+
+			if err := runSourcesReset(p, args); err != nil {
+				return fmt.Errorf("failed to reset sources: %w", err)
+			}
+
+			return nil
+		}),
+	}
+
+	sourcesCmd.AddCommand(cmd)
+}
+
+func runSourcesReset(p *project.Project, names []string) error {
+	for _, name := range names {
+		if err := p.ResetSource(name); err != nil {
+			return fmt.Errorf("failed to reset source %q: %w", name, err)
+		}
+
+		ref := "its configured ref"
+		if src, ok := p.Sources[name]; ok {
+			ref = formatSourceRef(src)
+		}
+
+		fmt.Printf("Source '%s' will be synced at %s again\n", name, ref)
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pilat/devbox/internal/app"
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/project"
+	"github.com/pilat/devbox/internal/source"
+	"github.com/pilat/devbox/internal/table"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show how sources differ from their configuration and remotes",
+		Long:  "That command shows the checked out ref, ahead/behind counts, local changes and mounts of every source",
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: validArgsWrapper(
+			func(ctx context.Context, cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			},
+		),
+		RunE: runWrapper(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			p, err := mgr.AutodetectProject(ctx, projectName)
+			if err != nil {
+				return fmt.Errorf("failed to detect project: %w", err)
+			}
+
+			if err := runSourcesStatus(ctx, p); err != nil {
+				return fmt.Errorf("failed to get sources status: %w", err)
+			}
+
+			return nil
+		}),
+	}
+
+	sourcesCmd.AddCommand(cmd)
+}
+
+func runSourcesStatus(ctx context.Context, p *project.Project) error {
+	if len(p.Sources) == 0 {
+		fmt.Println("Project has no sources")
+		return nil
+	}
+
+	t := table.New("Name", "Ref", "Checked out", "Ahead", "Behind", "Dirty", "Sparse checkout", "Mounted")
+	t.SortBy([]table.SortBy{{Name: "Name", Mode: table.Asc}})
+
+	for name, src := range p.Sources {
+		backend, err := source.New(src, source.Options{Dir: filepath.Join(p.WorkingDir, app.SourcesDir, name)})
+		if err != nil {
+			return fmt.Errorf("failed to get source %s: %w", name, err)
+		}
+
+		checkedOut, ahead, behind, dirty := "-", "-", "-", "-"
+		if reporter, ok := backend.(source.StatusReporter); ok {
+			status, err := reporter.GetStatus(ctx)
+			switch {
+			case errors.Is(err, source.ErrNotSynced):
+				checkedOut = "not synced"
+			case err != nil:
+				return fmt.Errorf("failed to get status of %s: %w", name, err)
+			default:
+				checkedOut = formatCheckout(status)
+				dirty = formatDirty(status.Dirty)
+				if status.Upstream != "" {
+					ahead, behind = strconv.Itoa(status.Ahead), strconv.Itoa(status.Behind)
+				}
+			}
+		}
+
+		mounted := formatSourceMounts(ctx, p.SourceMounts(name))
+
+		t.AppendRow(name, formatSyncedRef(p, name), checkedOut, ahead, behind, dirty,
+			strings.Join(src.SparseCheckout, ", "), mounted)
+	}
+
+	fmt.Println(" Sources:")
+	t.Render()
+
+	return nil
+}
+
+// formatCheckout describes what is checked out in the same way formatSourceRef describes the configured ref.
+func formatCheckout(status *git.Status) string {
+	switch {
+	case status.Branch != "":
+		return "branch " + status.Branch
+	case status.Tag != "":
+		return "tag " + status.Tag
+	default:
+		return "commit " + status.Commit[:min(len(status.Commit), 12)]
+	}
+}
+
+func formatDirty(dirty bool) string {
+	if dirty {
+		return "yes"
+	}
+
+	return "no"
+}
+
+// formatSourceMounts lists the local paths that replace the source, with the branch and dirtiness of each.
+func formatSourceMounts(ctx context.Context, mounts map[string]string) string {
+	if len(mounts) == 0 {
+		return "-"
+	}
+
+	mountPaths := make([]string, 0, len(mounts))
+	for mountPath := range mounts {
+		mountPaths = append(mountPaths, mountPath)
+	}
+
+	sort.Strings(mountPaths)
+
+	lines := make([]string, 0, len(mountPaths))
+	for _, mountPath := range mountPaths {
+		localPath := mounts[mountPath]
+
+		// The local path may be anything, not being a repository is not an error here
+		status, err := git.New(localPath).GetStatus(ctx)
+		if err != nil {
+			lines = append(lines, localPath)
+			continue
+		}
+
+		description := formatCheckout(status)
+		if status.Dirty {
+			description += ", dirty"
+		}
+
+		lines = append(lines, fmt.Sprintf("%s (%s)", localPath, description))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pilat/devbox/internal/git"
+)
+
+func TestFormatCheckout(t *testing.T) {
+	tests := []struct {
+		name   string
+		status *git.Status
+		want   string
+	}{
+		{name: "branch", status: &git.Status{Branch: "main", Commit: "3f9a1c7e5b2d4a6f"}, want: "branch main"},
+		{name: "tag", status: &git.Status{Tag: "v1.4.2", Commit: "3f9a1c7e5b2d4a6f"}, want: "tag v1.4.2"},
+		{name: "commit", status: &git.Status{Commit: "3f9a1c7e5b2d4a6f"}, want: "commit 3f9a1c7e5b2d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatCheckout(tt.status))
+		})
+	}
+}
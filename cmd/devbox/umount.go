@@ -12,6 +12,7 @@ import (
 
 func init() {
 	var sourceName string
+	var force bool
 
 	cmd := &cobra.Command{
 		Use:   "umount",
@@ -55,7 +56,7 @@ func init() {
 				return fmt.Errorf("failed to autodetect source: %w", err)
 			}
 
-			if err := runUmount(ctx, p, result.Sources); err != nil {
+			if err := runUmount(ctx, p, result.Sources, force); err != nil {
 				return fmt.Errorf("failed to unmount source code: %w", err)
 			}
 
@@ -63,6 +64,10 @@ func init() {
 				return fmt.Errorf("failed to restart services: %w", err)
 			}
 
+			if err := runHooks(ctx, p, project.HookPostUmount); err != nil {
+				return fmt.Errorf("failed to run hooks: %w", err)
+			}
+
 			if err := runInfo(ctx, p); err != nil {
 				return fmt.Errorf("failed to get project info: %w", err)
 			}
@@ -72,6 +77,7 @@ func init() {
 	}
 
 	cmd.PersistentFlags().StringVarP(&sourceName, "source", "s", "", "Source name")
+	cmd.PersistentFlags().BoolVar(&force, "force", false, "Discard local changes in worktrees without a backup")
 
 	_ = cmd.RegisterFlagCompletionFunc(
 		"source",
@@ -88,11 +94,15 @@ func init() {
 	root.AddCommand(cmd)
 }
 
-func runUmount(ctx context.Context, p *project.Project, sources []string) error {
+func runUmount(ctx context.Context, p *project.Project, sources []string, force bool) error {
 	err := p.Umount(ctx, sources)
 	if err != nil {
 		return fmt.Errorf("failed to unmount source code: %w", err)
 	}
 
+	if err := runRemoveWorktrees(ctx, p, force); err != nil {
+		return fmt.Errorf("failed to remove worktrees: %w", err)
+	}
+
 	return nil
 }
@@ -2,10 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
-	"time"
 
 	"github.com/spf13/cobra"
 
@@ -13,10 +13,12 @@ import (
 	"github.com/pilat/devbox/internal/cert"
 	"github.com/pilat/devbox/internal/hosts"
 	"github.com/pilat/devbox/internal/project"
+	"github.com/pilat/devbox/internal/settings"
 )
 
 func init() {
 	var profiles []string
+	var frozen, force bool
 
 	cmd := &cobra.Command{
 		Use:   "up",
@@ -43,8 +45,16 @@ func init() {
 				return fmt.Errorf("failed to detect project: %w", err)
 			}
 
-			if err := runProjectUpdate(ctx, p); err != nil {
-				return fmt.Errorf("failed to update project: %w", err)
+			if offline && frozen {
+				return errors.New("--frozen can't be used offline, the pinned commits may be missing")
+			}
+
+			var manifest *changelogEntry
+			if !offline {
+				manifest, err = runProjectUpdate(ctx, p, manifestTarget{})
+				if err != nil && (frozen || !fallbackOffline(err)) {
+					return fmt.Errorf("failed to update project: %w", err)
+				}
 			}
 
 			if err := runHostsUpdate(p, true, false); err != nil {
@@ -55,8 +65,24 @@ func init() {
 				return fmt.Errorf("failed to update certificates: %w", err)
 			}
 
-			if err := runSourcesUpdate(ctx, p); err != nil {
-				return fmt.Errorf("failed to update sources: %w", err)
+			var sources []changelogEntry
+			if !offline {
+				sources, err = runSourcesUpdate(ctx, p, syncOptions{frozen: frozen, force: force})
+				if err != nil && (frozen || !fallbackOffline(err)) {
+					return fmt.Errorf("failed to update sources: %w", err)
+				}
+			}
+
+			if offline {
+				if err := runOfflineReport(ctx, p); err != nil {
+					return fmt.Errorf("failed to report sources: %w", err)
+				}
+			} else {
+				printChangelog(newChangelog(manifest, sources))
+			}
+
+			if err := runHooks(ctx, p, project.HookPostSourceSync); err != nil {
+				return fmt.Errorf("failed to run hooks: %w", err)
 			}
 
 			if err := runBuild(ctx, p); err != nil {
@@ -69,15 +95,25 @@ func init() {
 				return fmt.Errorf("failed to reload project with profiles: %w", err)
 			}
 
+			if err := runHooks(ctx, p, project.HookPreUp); err != nil {
+				return fmt.Errorf("failed to run hooks: %w", err)
+			}
+
 			if err := runUp(ctx, p); err != nil {
 				return fmt.Errorf("failed to start project: %w", err)
 			}
 
+			if err := runHooks(ctx, p, project.HookPostUp); err != nil {
+				return fmt.Errorf("failed to run hooks: %w", err)
+			}
+
 			return nil
 		}),
 	}
 
 	cmd.PersistentFlags().StringSliceVarP(&profiles, "profile", "p", []string{}, "Profile to use")
+	cmd.Flags().BoolVar(&frozen, "frozen", false, "Check out the commits pinned in "+app.LockFile)
+	cmd.Flags().BoolVar(&force, "force", false, "Discard local changes in sources without a backup")
 
 	_ = cmd.RegisterFlagCompletionFunc(
 		"profile",
@@ -142,7 +178,7 @@ func runBuild(ctx context.Context, p *project.Project) error {
 }
 
 func runUp(ctx context.Context, p *project.Project) error {
-	timeout := 60 * time.Minute
+	timeout := settings.UpTimeout
 	opts := project.UpOptions{
 		Create: project.CreateOptions{
 			RemoveOrphans: true,
@@ -155,6 +191,10 @@ func runUp(ctx context.Context, p *project.Project) error {
 		},
 	}
 
+	if offline {
+		p.DisablePulls()
+	}
+
 	svc, err := newProgressCompose()
 	if err != nil {
 		return err
@@ -178,10 +218,8 @@ func runCertUpdate(p *project.Project, firstTime bool) error {
 	fmt.Println("[*] Setup CA...")
 
 	err := cert.SetupCA(app.AppDir)
-	if err != nil && firstTime {
-		args := []string{"--", binName, "install-ca", nameFlag, p.Name}
-
-		cmd := exec.Command("sudo", args...)
+	if err != nil && firstTime && settings.AutoSudo {
+		cmd := sudoCommand("install-ca", nameFlag, p.Name)
 		if err := cmd.Run(); err != nil {
 			return fmt.Errorf("failed to install CA: %w", err)
 		}
@@ -205,15 +243,15 @@ func runHostsUpdate(p *project.Project, firstTime, cleanup bool) error {
 	}
 
 	changed, err := hosts.Save(p.Name, entities)
-	if err != nil && firstTime {
+	if err != nil && firstTime && settings.AutoSudo {
 		// Permission denied - retry with sudo
-		args := []string{"--", binName, "update-hosts", nameFlag, p.Name}
+		args := []string{"update-hosts", nameFlag, p.Name}
 		if cleanup {
 			args = append(args, "--cleanup")
 		}
 
 		fmt.Println("[*] Update hosts file...")
-		cmd := exec.Command("sudo", args...)
+		cmd := sudoCommand(args...)
 		if err := cmd.Run(); err != nil {
 			return fmt.Errorf("failed to save hosts file: %w", err)
 		}
@@ -225,3 +263,10 @@ func runHostsUpdate(p *project.Project, firstTime, cleanup bool) error {
 
 	return nil
 }
+
+// sudoCommand runs devbox as root. The data root is passed on, sudo resets the environment and the home directory.
+func sudoCommand(args ...string) *exec.Cmd {
+	sudoArgs := append([]string{"--", "env", settings.EnvHome + "=" + app.AppDir, binName}, args...)
+
+	return exec.Command("sudo", sudoArgs...)
+}
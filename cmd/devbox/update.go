@@ -2,21 +2,29 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
-	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/docker/compose/v5/pkg/api"
 	"github.com/spf13/cobra"
 
 	"github.com/pilat/devbox/internal/app"
 	"github.com/pilat/devbox/internal/git"
 	"github.com/pilat/devbox/internal/project"
+	"github.com/pilat/devbox/internal/settings"
+	"github.com/pilat/devbox/internal/source"
+	"github.com/pilat/devbox/internal/table"
 )
 
 func init() {
+	var frozen, force bool
+	var changelogJSON string
+	var target manifestTarget
+
 	cmd := &cobra.Command{
 		Use:   "update",
 		Short: "Update devbox project sources",
@@ -28,21 +36,32 @@ func init() {
 			},
 		),
 		RunE: runWrapper(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			if offline {
+				return errors.New("update needs the remotes, it can't be used offline")
+			}
+
 			// We are attempting to update the project by its name before trying autodetection,
 			// as autodetection may fail if the project manifest is damaged.
-			updated := runEmergencyProjectUpdate(ctx, projectName)
+			target.follow = true
+			manifest := runEmergencyProjectUpdate(ctx, projectName, target)
 
 			p, err := mgr.AutodetectProject(ctx, projectName)
 			if err != nil {
 				return fmt.Errorf("failed to autodetect project: %w", err)
 			}
 
-			if !updated {
-				if err := runProjectUpdate(ctx, p); err != nil {
+			if manifest == nil {
+				manifest, err = runProjectUpdate(ctx, p, target)
+				if err != nil {
 					return fmt.Errorf("failed to update project: %w", err)
 				}
 			}
 
+			if target.preview {
+				printChangelog(newChangelog(manifest, nil))
+				return nil
+			}
+
 			if err := runHostsUpdate(p, true, false); err != nil {
 				return fmt.Errorf("failed to update hosts file: %w", err)
 			}
@@ -51,72 +70,132 @@ func init() {
 				return fmt.Errorf("failed to update certificates: %w", err)
 			}
 
-			if err := runSourcesUpdate(ctx, p); err != nil {
+			sources, err := runSourcesUpdate(ctx, p, syncOptions{frozen: frozen, force: force})
+			if err != nil {
 				return fmt.Errorf("failed to update sources: %w", err)
 			}
 
+			if err := runHooks(ctx, p, project.HookPostSourceSync); err != nil {
+				return fmt.Errorf("failed to run hooks: %w", err)
+			}
+
 			if err := runInfo(ctx, p); err != nil {
 				return fmt.Errorf("failed to get project info: %w", err)
 			}
 
+			changes := newChangelog(manifest, sources)
+			printChangelog(changes)
+
+			if changelogJSON != "" {
+				if err := writeChangelogJSON(changes, changelogJSON); err != nil {
+					return fmt.Errorf("failed to save changelog: %w", err)
+				}
+			}
+
 			return nil
 		}),
 	}
 
+	cmd.Flags().BoolVar(&frozen, "frozen", false, "Check out the commits pinned in "+app.LockFile)
+	cmd.Flags().BoolVar(&force, "force", false, "Discard local changes in sources without a backup")
+	cmd.Flags().StringVar(
+		&changelogJSON, "changelog-json", "", "Write the incoming changes as JSON to a file, - for stdout")
+	cmd.Flags().StringVar(&target.to, "to", "", "Hold the manifest at a revision instead of following its branch")
+	cmd.Flags().BoolVar(&target.rollback, "rollback", false, "Hold the manifest at the previous revision")
+	cmd.Flags().BoolVar(&target.preview, "preview", false, "Show how the manifest would change without updating")
+	cmd.MarkFlagsMutuallyExclusive("to", "rollback")
+
 	root.AddCommand(cmd)
 }
 
-func runEmergencyProjectUpdate(ctx context.Context, projectName string) bool {
+// runEmergencyProjectUpdate updates the manifest repository by the project name only, it returns nil when
+// that was not possible.
+func runEmergencyProjectUpdate(ctx context.Context, projectName string, target manifestTarget) *changelogEntry {
 	if projectName == "" {
-		return false
+		return nil
 	}
 
 	workingDir := filepath.Join(app.AppDir, projectName)
 	if _, err := os.Stat(workingDir); err != nil {
-		return false
+		return nil
 	}
 
-	fakeProject := &project.Project{
-		Project: &types.Project{},
+	fmt.Println("[*] Updating project...")
+
+	// The project is not loaded here, it's loaded from the updated manifest afterwards
+	manifest, err := updateManifest(ctx, git.New(workingDir), workingDir, projectName, target)
+	if err != nil {
+		return nil
 	}
-	fakeProject.WorkingDir = workingDir
-	fakeProject.Name = projectName
 
-	return runProjectUpdate(ctx, fakeProject) == nil
+	return manifest
 }
 
-func runProjectUpdate(ctx context.Context, p *project.Project) error {
-	g := git.New(p.WorkingDir)
-
+func runProjectUpdate(ctx context.Context, p *project.Project, target manifestTarget) (*changelogEntry, error) {
 	fmt.Println("[*] Updating project...")
 
-	err := g.Pull(ctx) // TODO: consider using git.Sync() to reset it every time
+	manifest, err := updateManifest(ctx, git.New(p.WorkingDir), p.WorkingDir, p.Name, target)
 	if err != nil {
-		return fmt.Errorf("failed to pull git repo: %w", err)
+		return nil, err
 	}
 
-	_, err = g.GetInfo(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get git info: %w", err)
+	if target.preview {
+		return manifest, nil
 	}
 
 	if err := p.Reload(ctx, []string{"*"}); err != nil {
-		return fmt.Errorf("failed to reload project: %w", err)
+		return nil, fmt.Errorf("failed to reload project: %w", err)
 	}
 
-	return nil
+	return manifest, nil
+}
+
+// syncOptions controls how sources are synced by up, update and restart.
+type syncOptions struct {
+	frozen bool // check out the commits pinned in the lock file
+	force  bool // discard local changes in sources without a backup
+}
+
+// syncResult is what syncing the sources left to report.
+type syncResult struct {
+	backups []sourceBackup
+	changes []changelogEntry
+}
+
+// sourceBackup is the local work of a source saved before the sync discarded it.
+type sourceBackup struct {
+	name    string
+	changes *git.LocalChanges
+	dir     string
 }
 
-func runSourcesUpdate(ctx context.Context, p *project.Project) error {
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+func runSourcesUpdate(ctx context.Context, p *project.Project, opts syncOptions) ([]changelogEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, settings.SyncTimeout)
 	defer cancel()
 
+	commits := map[string]string{}
+	if opts.frozen {
+		for name, ref := range p.SourceOverrides {
+			if _, ok := p.Sources[name]; ok {
+				return nil, fmt.Errorf(
+					"source %q is checked out at %q, run 'devbox sources reset %s' to sync the pinned commit",
+					name, ref, name)
+			}
+		}
+
+		var err error
+		commits, err = p.LockedCommits()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get locked commits: %w", err)
+		}
+	}
+
 	fmt.Println("[*] Updating sources...")
 
 	bus := newProgressBus()
 	bus.Start(ctx, "sources")
 
-	err := syncSources(ctx, p,
+	result, err := syncSources(ctx, p, commits, opts.force,
 		func(name string) { bus.On(api.Resource{ID: name, Status: api.Working, Text: "Syncing"}) },
 		func(name string) { bus.On(api.Resource{ID: name, Status: api.Done, Text: "Synced"}) },
 		func(name string) { bus.On(api.Resource{ID: name, Status: api.Error, Text: "Failed"}) },
@@ -124,17 +203,45 @@ func runSourcesUpdate(ctx context.Context, p *project.Project) error {
 
 	bus.Done("sources", err == nil)
 
-	return err
+	printSourceBackups(result.backups)
+
+	return result.changes, err
+}
+
+func printSourceBackups(backups []sourceBackup) {
+	if len(backups) == 0 {
+		return
+	}
+
+	t := table.New("Name", "Files", "Commits", "Backup")
+	t.SortBy([]table.SortBy{{Name: "Name", Mode: table.Asc}})
+
+	for _, b := range backups {
+		t.AppendRow(b.name, len(b.changes.Files), len(b.changes.Commits), b.dir)
+	}
+
+	fmt.Println("")
+	fmt.Println(" Local changes in sources were saved before the sync:")
+	t.Render()
+	fmt.Println("Restore files with 'git apply <patch>' and commits with 'git fetch <bundle>' in the source directory")
 }
 
-func syncSources(ctx context.Context, p *project.Project, onSyncing, onSynced, onFailed func(name string)) error {
+func syncSources(
+	ctx context.Context,
+	p *project.Project,
+	commits map[string]string,
+	force bool,
+	onSyncing, onSynced, onFailed func(name string),
+) (syncResult, error) {
 	ctx, cancelSync := context.WithCancel(ctx)
 	defer cancelSync()
 
 	excludes := p.SourceCleanExcludes()
 
-	const maxConcurrency = 4
-	sem := make(chan struct{}, maxConcurrency)
+	var mu sync.Mutex
+	result := syncResult{backups: []sourceBackup{}, changes: []changelogEntry{}}
+
+	sem := make(chan struct{}, settings.SyncConcurrency)
 
 	errCh := make(chan error, len(p.Sources))
 	for name, src := range p.Sources {
@@ -149,13 +256,72 @@ func syncSources(ctx context.Context, p *project.Project, onSyncing, onSynced, o
 
 			onSyncing(name)
 
-			repoDir := filepath.Join(p.WorkingDir, app.SourcesDir, name)
-
-			g := git.New(repoDir, excludes[name]...)
-			err := g.Sync(ctx, src.URL, src.Branch, src.SparseCheckout)
+			dir := filepath.Join(p.WorkingDir, app.SourcesDir, name)
+			backend, err := source.New(src, source.Options{
+				Dir:      dir,
+				Excludes: excludes[name],
+				Commit:   commits[name],
+				Ref:      p.SourceOverrides[name],
+			})
 			if err != nil {
 				onFailed(name)
 				cancelSync()
+				errCh <- err
+				return
+			}
+
+			tracker, ok := backend.(source.ChangeTracker)
+			if !ok {
+				// A path or an archive source replaces the checkout the source had as a git one
+				tracker, ok = source.PreviousCheckout(dir)
+			}
+
+			if ok && !force {
+				backup, err := backupLocalChanges(ctx, p, name, tracker)
+				if err != nil {
+					onFailed(name)
+					cancelSync()
+					errCh <- err
+					return
+				}
+
+				if backup.dir != "" {
+					mu.Lock()
+					result.backups = append(result.backups, backup)
+					mu.Unlock()
+				}
+			}
+
+			history, hasHistory := backend.(source.HistoryReader)
+
+			head := ""
+			if hasHistory {
+				head, err = history.GetHead(ctx)
+				if err != nil {
+					onFailed(name)
+					cancelSync()
+					errCh <- err
+					return
+				}
+			}
+
+			err = backend.Sync(ctx)
+			if err == nil && hasHistory {
+				var change changelogEntry
+				change, err = readSourceChangelog(ctx, history, name, head)
+
+				mu.Lock()
+				result.changes = append(result.changes, change)
+				mu.Unlock()
+			}
+
+			if err != nil {
+				onFailed(name)
+
+				// An unreachable remote is worked around offline, the other sources must not be left half synced
+				if !errors.Is(err, git.ErrRemoteUnreachable) {
+					cancelSync()
+				}
 			} else {
 				onSynced(name)
 			}
@@ -166,14 +332,57 @@ func syncSources(ctx context.Context, p *project.Project, onSyncing, onSynced, o
 
 	var firstErr error
 	for range p.Sources {
-		if err := <-errCh; err != nil && firstErr == nil {
+		// The first failure cancels the other syncs, their cancellation must not hide it. An unreachable remote
+		// doesn't cancel them, so a failure that did must not be hidden by it either.
+		if err := <-errCh; err != nil && (firstErr == nil || errors.Is(firstErr, context.Canceled) ||
+			errors.Is(firstErr, git.ErrRemoteUnreachable) && !errors.Is(err, context.Canceled)) {
 			firstErr = err
 		}
 	}
 
 	if firstErr != nil {
-		return fmt.Errorf("failed to sync source: %w", firstErr)
+		return result, fmt.Errorf("failed to sync source: %w", firstErr)
+	}
+
+	return result, nil
+}
+
+// readSourceChangelog describes what the sync brought into the source that was at commit from before.
+func readSourceChangelog(
+	ctx context.Context,
+	history source.HistoryReader,
+	name, from string,
+) (changelogEntry, error) {
+	to, err := history.GetHead(ctx)
+	if err != nil {
+		return changelogEntry{}, fmt.Errorf("failed to get head of source %q: %w", name, err)
+	}
+
+	return readChangelog(ctx, history, name, from, to)
+}
+
+// backupLocalChanges saves uncommitted files and unpushed commits of the source, because the sync resets it.
+// The sync must not go on when the backup fails.
+func backupLocalChanges(
+	ctx context.Context,
+	p *project.Project,
+	name string,
+	g source.ChangeTracker,
+) (sourceBackup, error) {
+	changes, err := g.GetLocalChanges(ctx)
+	if err != nil {
+		return sourceBackup{}, fmt.Errorf("failed to check local changes of source %q: %w", name, err)
+	}
+
+	if changes.IsEmpty() {
+		return sourceBackup{}, nil
+	}
+
+	dir := p.SourceBackupDir(name, time.Now())
+	if _, err := g.Backup(ctx, dir, changes); err != nil {
+		return sourceBackup{}, fmt.Errorf(
+			"failed to back up local changes of source %q, use --force to discard them: %w", name, err)
 	}
 
-	return nil
+	return sourceBackup{name: name, changes: changes, dir: dir}, nil
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/project"
+)
+
+func TestBackupLocalChanges(t *testing.T) {
+	dirty := &git.LocalChanges{Files: []string{"main.go"}, Commits: []string{"abc1234 wip"}}
+
+	tests := []struct {
+		name      string
+		changes   *git.LocalChanges
+		backupErr error
+		wantDir   bool
+		wantErr   string
+	}{
+		{name: "clean source", changes: &git.LocalChanges{}},
+		{name: "dirty source", changes: dirty, wantDir: true},
+		{name: "backup fails", changes: dirty, backupErr: errors.New("disk full"), wantErr: "use --force"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &project.Project{Project: &types.Project{WorkingDir: t.TempDir()}}
+
+			g := git.NewMockService(t)
+			g.EXPECT().GetLocalChanges(mock.Anything).Return(tt.changes, nil)
+			if !tt.changes.IsEmpty() {
+				g.EXPECT().Backup(mock.Anything, mock.Anything, tt.changes).Return(nil, tt.backupErr)
+			}
+
+			backup, err := backupLocalChanges(t.Context(), p, "api", g)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("backupLocalChanges() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("backupLocalChanges() error = %v", err)
+			}
+
+			wantPrefix := filepath.Join(p.WorkingDir, "sources", ".backups", "api")
+			if tt.wantDir != strings.HasPrefix(backup.dir, wantPrefix) {
+				t.Errorf("backupLocalChanges() dir = %q, want it in %q: %v", backup.dir, wantPrefix, tt.wantDir)
+			}
+		})
+	}
+}
+
+func TestSyncSources_GitCheckoutReplacedByPath(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	tests := []struct {
+		name       string
+		force      bool
+		wantBackup bool
+	}{
+		{name: "local changes are backed up", wantBackup: true},
+		{name: "force discards local changes", force: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workingDir := t.TempDir()
+			dir := filepath.Join(workingDir, "sources", "api")
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			runGit := func(args ...string) {
+				t.Helper()
+				cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+				cmd.Env = append(os.Environ(),
+					"GIT_CONFIG_GLOBAL=/dev/null",
+					"GIT_CONFIG_SYSTEM=/dev/null",
+					"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t",
+					"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t",
+				)
+				if out, err := cmd.CombinedOutput(); err != nil {
+					t.Fatalf("git %v: %v\n%s", args, err, out)
+				}
+			}
+
+			runGit("init", "-q")
+			if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			runGit("add", "main.go")
+			runGit("commit", "-q", "-m", "init")
+			if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main // wip\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			shared := t.TempDir()
+			p := &project.Project{
+				Project: &types.Project{WorkingDir: workingDir},
+				Sources: project.SourceConfigs{"api": {Type: project.SourceTypePath, Path: shared}},
+			}
+
+			noop := func(string) {}
+			result, err := syncSources(t.Context(), p, nil, tt.force, noop, noop, noop)
+			if err != nil {
+				t.Fatalf("syncSources() error = %v", err)
+			}
+
+			if target, err := os.Readlink(dir); err != nil || target != shared {
+				t.Errorf("source is not linked to %s: %q, %v", shared, target, err)
+			}
+
+			if (len(result.backups) == 1) != tt.wantBackup {
+				t.Fatalf("syncSources() backups = %+v, want backup: %v", result.backups, tt.wantBackup)
+			}
+
+			if tt.wantBackup {
+				patch, err := os.ReadFile(filepath.Join(result.backups[0].dir, "changes.patch"))
+				if err != nil || !strings.Contains(string(patch), "// wip") {
+					t.Errorf("backup has no local changes: %q, %v", patch, err)
+				}
+			}
+		})
+	}
+}
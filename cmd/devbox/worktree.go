@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pilat/devbox/internal/app"
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/project"
+	"github.com/pilat/devbox/internal/source"
+)
+
+// runAddWorktree creates a worktree with the branch checked out for the detected sources and returns the path to
+// mount. The worktree belongs to the developer's own clone when the sources were detected from it, otherwise to the
+// synced source.
+func runAddWorktree(
+	ctx context.Context, p *project.Project, sources []string, localPath, branch string,
+) (string, error) {
+	sourcePath := localPath
+	if sourcePath == "" {
+		sourcePath = filepath.Join(p.WorkingDir, sources[0])
+	}
+
+	repo, err := git.New(sourcePath).GetTopLevel(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get repository of %s: %w", sourcePath, err)
+	}
+
+	// The source may be mounted by a path inside the repository, the worktree has the same layout
+	subpath, err := filepath.Rel(repo, sourcePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get relative path: %w", err)
+	}
+
+	name, _, _ := strings.Cut(strings.TrimPrefix(sources[0], "./"+app.SourcesDir+"/"), "/")
+	dir := p.SourceWorktreeDir(name, branch)
+
+	if _, ok := p.Worktrees[dir]; !ok {
+		fmt.Printf("[*] Creating worktree of %s for branch %s...\n", name, branch)
+
+		if err := git.New(repo).AddWorktree(ctx, dir, branch); err != nil {
+			return "", fmt.Errorf("failed to create worktree: %w", err)
+		}
+
+		// Saved before the mount, so a failed mount doesn't leave the worktree behind unnoticed
+		p.Worktrees[dir] = repo
+
+		if err := p.SaveState(); err != nil {
+			return "", fmt.Errorf("failed to save state: %w", err)
+		}
+	}
+
+	return filepath.Join(dir, subpath), nil
+}
+
+// runRemoveWorktrees removes the worktrees that no mount uses anymore. Local changes in them are backed up first
+// unless force is set.
+func runRemoveWorktrees(ctx context.Context, p *project.Project, force bool) error {
+	return removeWorktrees(ctx, p, p.UnusedWorktrees(), force)
+}
+
+// removeWorktrees removes the worktrees, mapped to their repositories, and saves the state.
+func removeWorktrees(ctx context.Context, p *project.Project, worktrees map[string]string, force bool) error {
+	if len(worktrees) == 0 {
+		return nil
+	}
+
+	fmt.Println("[*] Removing worktrees...")
+
+	var removeErr error
+	backups := []sourceBackup{}
+	for dir, repo := range worktrees {
+		backup, err := removeWorktree(ctx, p, dir, repo, force)
+		if err != nil {
+			removeErr = err
+			break
+		}
+
+		if backup.dir != "" {
+			backups = append(backups, backup)
+		}
+	}
+
+	printSourceBackups(backups)
+
+	// The worktrees removed before a failure are gone anyway
+	if err := p.SaveState(); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	return removeErr
+}
+
+// removeWorktree removes a worktree created by devbox and forgets it. The worktree stays when its local changes
+// can't be backed up.
+func removeWorktree(ctx context.Context, p *project.Project, dir, repo string, force bool) (sourceBackup, error) {
+	backup := sourceBackup{}
+	if !force {
+		var err error
+		backup, err = backupLocalChanges(ctx, p, worktreeSourceName(p, dir), worktreeChanges{git.New(dir)})
+		if err != nil {
+			return sourceBackup{}, err
+		}
+	}
+
+	if err := git.New(repo).RemoveWorktree(ctx, dir); err != nil {
+		return backup, fmt.Errorf("failed to remove worktree %s: %w", dir, err)
+	}
+
+	delete(p.Worktrees, dir)
+
+	return backup, nil
+}
+
+// worktreeChanges reports only the files of a worktree as its local changes. Its commits stay on the branch when
+// the worktree is removed, and the branches of the repository are shared by all of its worktrees.
+type worktreeChanges struct {
+	source.ChangeTracker
+}
+
+func (w worktreeChanges) GetLocalChanges(ctx context.Context) (*git.LocalChanges, error) {
+	changes, err := w.ChangeTracker.GetLocalChanges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &git.LocalChanges{Files: changes.Files}, nil
+}
+
+// worktreeSourceName returns the name of the source the worktree was created for, its backups go with the source.
+func worktreeSourceName(p *project.Project, dir string) string {
+	rel, err := filepath.Rel(filepath.Join(p.WorkingDir, app.SourcesDir, project.WorktreesDir), dir)
+	if err != nil {
+		return filepath.Base(dir)
+	}
+
+	name, _, _ := strings.Cut(rel, string(filepath.Separator))
+
+	return name
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+
+	"github.com/pilat/devbox/internal/project"
+)
+
+func TestRemoveWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	tests := []struct {
+		name       string
+		force      bool
+		wantBackup bool
+	}{
+		{name: "local changes are backed up", wantBackup: true},
+		{name: "force discards local changes", force: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := t.TempDir()
+			runGit := func(args ...string) {
+				t.Helper()
+				cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+				cmd.Env = append(os.Environ(),
+					"GIT_CONFIG_GLOBAL=/dev/null",
+					"GIT_CONFIG_SYSTEM=/dev/null",
+					"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t",
+					"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t",
+				)
+				if out, err := cmd.CombinedOutput(); err != nil {
+					t.Fatalf("git %v: %v\n%s", args, err, out)
+				}
+			}
+
+			runGit("init", "-q")
+			if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			runGit("add", "main.go")
+			runGit("commit", "-q", "-m", "init")
+
+			p := &project.Project{Project: &types.Project{WorkingDir: t.TempDir()}}
+			dir := p.SourceWorktreeDir("api", "feature/login")
+			runGit("worktree", "add", "-q", "-b", "feature/login", dir)
+			p.Worktrees = map[string]string{dir: repo}
+
+			if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main // wip\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			backup, err := removeWorktree(t.Context(), p, dir, repo, tt.force)
+			if err != nil {
+				t.Fatalf("removeWorktree() error = %v", err)
+			}
+
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Errorf("worktree %s is not removed: %v", dir, err)
+			}
+
+			if len(p.Worktrees) != 0 {
+				t.Errorf("worktree is not forgotten: %v", p.Worktrees)
+			}
+
+			if (backup.dir != "") != tt.wantBackup {
+				t.Fatalf("removeWorktree() backup = %+v, want backup: %v", backup, tt.wantBackup)
+			}
+
+			if !tt.wantBackup {
+				return
+			}
+
+			if backup.name != "api" || len(backup.changes.Commits) != 0 {
+				t.Errorf("removeWorktree() backup = %+v, want files of source api only", backup)
+			}
+
+			patch, err := os.ReadFile(filepath.Join(backup.dir, "changes.patch"))
+			if err != nil || !strings.Contains(string(patch), "// wip") {
+				t.Errorf("backup has no local changes: %q, %v", patch, err)
+			}
+		})
+	}
+}
@@ -11,6 +11,11 @@ const (
 	SourcesDir = "sources"
 	StateFile  = ".devboxstate"
 	EnvFile    = ".env"
+	LockFile   = "devbox.lock"
+	CacheDir   = ".cache"      // shared between projects, dot-prefixed so it's never taken for a project
+	IndexFile  = ".index.json" // what project autodetection knows about the projects
+	ReposDir   = ".repos"      // repos of the projects whose manifest is in a subdirectory
+	BackupsDir = ".backups"    // backups of local changes in the sources of destroyed projects
 )
 
 func init() {
@@ -45,6 +45,38 @@ func GeneratePair(appDir, certFile, keyFile string, hosts []string) error {
 	return c.generatePair(certFile, keyFile, hosts)
 }
 
+// CAState is how far the CA is from being usable.
+type CAState int
+
+const (
+	CAMissing   CAState = iota // not generated yet
+	CAExpired                  // generated, but expired
+	CAUntrusted                // valid, but not in the system trust store
+	CATrusted
+)
+
+// CheckCA tells the state of the CA without changing anything, unlike SetupCA.
+func CheckCA(appDir string) (CAState, error) {
+	c := &cert{
+		certFile: filepath.Join(appDir, "ca.crt"),
+		keyFile:  filepath.Join(appDir, "ca.key"),
+	}
+
+	err := c.loadCA()
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return CAMissing, nil
+	case err != nil:
+		return CAMissing, fmt.Errorf("failed to load certificate: %w", err)
+	case c.isCAExpired():
+		return CAExpired, nil
+	case !c.isSynced():
+		return CAUntrusted, nil
+	}
+
+	return CATrusted, nil
+}
+
 // setup sets up the certificate authority. Might require sudo on some systems.
 func (c *cert) setupCA() error {
 	err := c.loadCA()
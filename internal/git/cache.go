@@ -0,0 +1,117 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	cacheLocks sync.Map // cache path -> *sync.Mutex
+
+	unsafeCacheChars = regexp.MustCompile(`[^a-z0-9._/-]+`)
+)
+
+// CachePath returns the shared bare repository for url under root. Different URLs of the same repository
+// (HTTPS, SSH) share one cache.
+func CachePath(root, url string) string {
+	key := unsafeCacheChars.ReplaceAllString(NormalizeURL(url), "_")
+
+	parts := []string{root}
+	for _, part := range strings.Split(key, "/") {
+		if part != "" && part != "." && part != ".." {
+			parts = append(parts, part)
+		}
+	}
+
+	return filepath.Join(parts...) + ".git"
+}
+
+// updateCache creates or refreshes the shared bare repository, so a new clone can borrow its objects.
+// Automatic gc is disabled in the cache: clones refer to its objects and must never lose them.
+func (s *svc) updateCache(ctx context.Context, url, cache string) error {
+	lock, _ := cacheLocks.LoadOrStore(cache, &sync.Mutex{})
+	mu, _ := lock.(*sync.Mutex)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, err := os.Stat(filepath.Join(cache, "HEAD")); err == nil {
+		out, err := s.runner.RunWithTTY(
+			ctx, "git", "-C", cache, "fetch", "--tags", "origin", "+refs/heads/*:refs/heads/*")
+		if err != nil {
+			return fmt.Errorf("failed to update cache: %s %w", out, err)
+		}
+
+		return nil
+	}
+
+	_ = os.RemoveAll(cache)
+	if err := os.MkdirAll(filepath.Dir(cache), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	out, err := s.runner.RunWithTTY(ctx, "git", "clone", "--bare", url, cache)
+	if err != nil {
+		return fmt.Errorf("failed to create cache: %s\n%s\n%w", out, gitConfigHint(url), err)
+	}
+
+	out, err = s.runner.Run(ctx, "git", "-C", cache, "config", "gc.auto", "0")
+	if err != nil {
+		return fmt.Errorf("failed to configure cache: %s %w", out, err)
+	}
+
+	return nil
+}
+
+// ListCaches returns all shared bare repositories under root.
+func ListCaches(root string) ([]string, error) {
+	caches := []string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() && strings.HasSuffix(path, ".git") {
+			caches = append(caches, path)
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return caches, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to list caches: %w", err)
+	}
+
+	return caches, nil
+}
+
+// ReferencedCaches returns the repositories whose objects are borrowed by the repositories in repoDirs
+// through git alternates.
+func ReferencedCaches(repoDirs []string) map[string]bool {
+	caches := map[string]bool{}
+	for _, dir := range repoDirs {
+		file, err := os.Open(filepath.Join(dir, ".git", "objects", "info", "alternates"))
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+				caches[filepath.Dir(filepath.Clean(line))] = true
+			}
+		}
+
+		_ = file.Close()
+	}
+
+	return caches
+}
@@ -0,0 +1,119 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachePath(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "https", url: "https://github.com/Org/Repo.git", want: "/cache/github.com/org/repo.git"},
+		{name: "ssh", url: "git@github.com:org/repo.git", want: "/cache/github.com/org/repo.git"},
+		{
+			name: "port and spaces",
+			url:  "https://git.local:8443/my team/repo",
+			want: "/cache/git.local_8443/my_team/repo.git",
+		},
+		{name: "no traversal", url: "https://host/../../etc", want: "/cache/host/etc.git"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CachePath("/cache", tt.url))
+		})
+	}
+}
+
+func TestListCachesAndReferencedCaches(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "cache")
+	used := filepath.Join(root, "github.com", "org", "api.git")
+	unused := filepath.Join(root, "github.com", "org", "web.git")
+
+	for _, dir := range []string{filepath.Join(used, "objects"), filepath.Join(unused, "objects")} {
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+	}
+
+	caches, err := ListCaches(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{used, unused}, caches)
+
+	repo := filepath.Join(t.TempDir(), "api")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git", "objects", "info"), 0o755))
+	alternates := filepath.Join(repo, ".git", "objects", "info", "alternates")
+	require.NoError(t, os.WriteFile(alternates, []byte(filepath.Join(used, "objects")+"\n"), 0o644))
+
+	notRepo := t.TempDir()
+
+	assert.Equal(t, map[string]bool{used: true}, ReferencedCaches([]string{repo, notRepo}))
+
+	caches, err = ListCaches(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, caches)
+}
+
+func TestSyncWithSharedCache(t *testing.T) {
+	const url = "https://github.com/org/repo.git"
+
+	tests := []struct {
+		name       string
+		cacheReady bool
+		setupMock  func(m *MockCommandRunner, targetPath, cache string)
+	}{
+		{
+			name: "cache is created before the first clone",
+			setupMock: func(m *MockCommandRunner, targetPath, cache string) {
+				m.EXPECT().RunWithTTY(mock.Anything, "git", "clone", "--bare", url, cache).Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", cache, "config", "gc.auto", "0").Return("", nil)
+			},
+		},
+		{
+			name:       "existing cache is refreshed",
+			cacheReady: true,
+			setupMock: func(m *MockCommandRunner, targetPath, cache string) {
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", cache, "fetch", "--tags", "origin",
+						"+refs/heads/*:refs/heads/*").
+					Return("", nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			targetPath := filepath.Join(dir, "repo")
+			cache := filepath.Join(dir, "cache", "github.com", "org", "repo.git")
+
+			if tt.cacheReady {
+				require.NoError(t, os.MkdirAll(cache, 0o755))
+				require.NoError(t, os.WriteFile(filepath.Join(cache, "HEAD"), []byte("ref: refs/heads/main\n"), 0o644))
+			}
+
+			runner := NewMockCommandRunner(t)
+			tt.setupMock(runner, targetPath, cache)
+			runner.EXPECT().
+				RunWithTTY(mock.Anything, "git", "clone", "--no-checkout", "--depth", "1", "--reference", cache, url,
+					targetPath).
+				Return("", nil)
+			runner.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+			runner.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "checkout", "main").Return("", nil)
+			runner.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil)
+			runner.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fd").Return("", nil)
+			runner.EXPECT().RunWithTTY(mock.Anything, "git", "-C", targetPath, "pull", "--rebase").Return("", nil)
+
+			svc := newSvcWithRunner(targetPath, runner)
+			err := svc.Sync(context.Background(), SyncOptions{URL: url, Branch: "main", Cache: cache})
+			require.NoError(t, err)
+		})
+	}
+}
@@ -2,10 +2,30 @@ package git
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 )
 
+// ErrRemoteUnreachable is returned when git could not connect to the remote at all, as opposed to being
+// refused by it.
+var ErrRemoteUnreachable = errors.New("remote is unreachable")
+
+// unreachableMessages are the messages of git, curl and ssh about connection failures.
+var unreachableMessages = []string{
+	"could not resolve host",
+	"could not resolve hostname",
+	"temporary failure in name resolution",
+	"failed to connect to",
+	"connection timed out",
+	"operation timed out",
+	"network is unreachable",
+	"no route to host",
+	"connection refused",
+}
+
 // CommandRunner executes shell commands.
 type CommandRunner interface {
 	Run(ctx context.Context, name string, args ...string) (string, error)
@@ -18,12 +38,29 @@ func (r *defaultRunner) Run(ctx context.Context, name string, args ...string) (s
 	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
 	out, err := cmd.CombinedOutput()
-	return string(out), err
+	return string(out), checkUnreachable(string(out), err)
 }
 
 func (r *defaultRunner) RunWithTTY(ctx context.Context, name string, args ...string) (string, error) {
 	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Stdin = os.Stdin
 	out, err := cmd.CombinedOutput()
-	return string(out), err
+	return string(out), checkUnreachable(string(out), err)
+}
+
+// checkUnreachable marks the error of a command with ErrRemoteUnreachable when its output tells that the
+// remote could not be connected to.
+func checkUnreachable(out string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	out = strings.ToLower(out)
+	for _, msg := range unreachableMessages {
+		if strings.Contains(out, msg) {
+			return fmt.Errorf("%w: %w", ErrRemoteUnreachable, err)
+		}
+	}
+
+	return err
 }
@@ -0,0 +1,51 @@
+package git
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckUnreachable(t *testing.T) {
+	exitErr := errors.New("exit status 128")
+
+	tests := []struct {
+		name            string
+		out             string
+		err             error
+		wantUnreachable bool
+	}{
+		{name: "success", out: "Already up to date.", err: nil},
+		{
+			name:            "dns failure",
+			out:             "fatal: unable to access 'https://github.com/org/repo.git/': Could not resolve host",
+			err:             exitErr,
+			wantUnreachable: true,
+		},
+		{
+			name:            "ssh timeout",
+			out:             "ssh: connect to host github.com port 22: Operation timed out",
+			err:             exitErr,
+			wantUnreachable: true,
+		},
+		{
+			name: "authentication failure",
+			out:  "git@github.com: Permission denied (publickey).\nfatal: Could not read from remote repository.",
+			err:  exitErr,
+		},
+		{name: "missing ref", out: "fatal: couldn't find remote ref refs/heads/nope", err: exitErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkUnreachable(tt.out, tt.err)
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("checkUnreachable() = %v, should keep %v", err, tt.err)
+			}
+
+			if errors.Is(err, ErrRemoteUnreachable) != tt.wantUnreachable {
+				t.Errorf("checkUnreachable() = %v, unreachable should be %v", err, tt.wantUnreachable)
+			}
+		})
+	}
+}
@@ -5,13 +5,30 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Service interface {
 	Clone(ctx context.Context, url, branch string) error
 	SetLocalExclude(patterns []string) error
-	Sync(ctx context.Context, url, branch string, sparseCheckout []string) error
+	AddLocalExclude(ctx context.Context, patterns []string) error
+	Sync(ctx context.Context, opts SyncOptions) error
+	ResolveRef(ctx context.Context, url, ref string) (string, error)
+	GetLocalChanges(ctx context.Context) (*LocalChanges, error)
+	HasChanges(ctx context.Context, paths ...string) (bool, error)
+	Backup(ctx context.Context, dir string, changes *LocalChanges) ([]string, error)
+	GetStatus(ctx context.Context) (*Status, error)
+	GetChangelog(ctx context.Context, from, to string) (*Changelog, error)
+	GetLastFetch(ctx context.Context) (time.Time, error)
+	Fetch(ctx context.Context) error
+	ResetTo(ctx context.Context, branch, rev string) error
+	ResolveRevision(ctx context.Context, rev string) (string, error)
+	GetTrackedBranch(ctx context.Context) (string, error)
+	AddWorktree(ctx context.Context, path, branch string) error
+	RemoveWorktree(ctx context.Context, path string) error
 	Pull(ctx context.Context) error
 	GetInfo(ctx context.Context) (*CommitInfo, error)
 	GetRemote(ctx context.Context) (string, error)
@@ -36,6 +53,26 @@ func New(targetFolder string, excludes ...string) Service {
 	}
 }
 
+// Version returns the version of the git binary, e.g. "2.39.2".
+func Version(ctx context.Context) (string, error) {
+	return version(ctx, &defaultRunner{})
+}
+
+func version(ctx context.Context, runner CommandRunner) (string, error) {
+	out, err := runner.Run(ctx, "git", "version")
+	if err != nil {
+		return "", fmt.Errorf("failed to get git version: %s %w", out, err)
+	}
+
+	// git version 2.39.2 (Apple Git-143)
+	fields := strings.Fields(out)
+	if len(fields) < 3 {
+		return "", fmt.Errorf("unexpected git version output: %s", out)
+	}
+
+	return fields[2], nil
+}
+
 func (s *svc) Clone(ctx context.Context, url, branch string) error {
 	args := []string{"clone", url, s.targetPath}
 	if branch != "" {
@@ -68,7 +105,67 @@ func (s *svc) SetLocalExclude(patterns []string) error {
 	return nil
 }
 
-func (s *svc) Sync(ctx context.Context, url, branch string, sparseCheckout []string) error {
+// AddLocalExclude appends the patterns missing from the exclude file, unlike SetLocalExclude it keeps what's
+// there. It's meant for repos devbox doesn't own, worktrees included.
+func (s *svc) AddLocalExclude(ctx context.Context, patterns []string) error {
+	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "rev-parse", "--git-path", "info/exclude")
+	if err != nil {
+		return fmt.Errorf("failed to locate exclude file: %s %w", out, err)
+	}
+
+	excludeFile := strings.TrimSpace(out)
+	if !filepath.IsAbs(excludeFile) {
+		excludeFile = filepath.Join(s.targetPath, excludeFile)
+	}
+
+	content, err := os.ReadFile(excludeFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read exclude file: %w", err)
+	}
+
+	existing := strings.Split(string(content), "\n")
+
+	var missing []string
+	for _, pattern := range patterns {
+		if !slices.Contains(existing, pattern) {
+			missing = append(missing, pattern)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(excludeFile), 0o755); err != nil {
+		return fmt.Errorf("failed to create exclude directory: %w", err)
+	}
+
+	file, err := os.OpenFile(excludeFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open exclude file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	prefix := ""
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		prefix = "\n"
+	}
+
+	if _, err := file.WriteString(prefix + strings.Join(missing, "\n") + "\n"); err != nil {
+		return fmt.Errorf("failed to write to exclude file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *svc) Sync(ctx context.Context, opts SyncOptions) error {
+	if opts.LFS {
+		// Check it first, a failed smudge in the middle of a checkout is much harder to understand
+		if out, err := s.runner.Run(ctx, "git", "lfs", "version"); err != nil {
+			return fmt.Errorf("git-lfs is required by the source but is not available: %s %w", out, err)
+		}
+	}
+
 	// if there is no `.git` directory we should not try to reset because it will try to lock a repo above
 	if _, err := os.Stat(filepath.Join(s.targetPath, ".git")); os.IsNotExist(err) {
 		_ = os.RemoveAll(s.targetPath)
@@ -83,15 +180,33 @@ func (s *svc) Sync(ctx context.Context, url, branch string, sparseCheckout []str
 		if err := s.reset(ctx, true); err != nil {
 			return fmt.Errorf("failed to reset repo %s: %w", s.targetPath, err)
 		}
+
+		if err := s.deepen(ctx, opts); err != nil {
+			return err
+		}
 	} else {
 		_ = os.MkdirAll(s.targetPath, os.ModePerm)
-		out, err := s.runner.RunWithTTY(ctx, "git", "clone", "--no-checkout", "--depth", "1", url, s.targetPath)
+
+		args := append([]string{"clone", "--no-checkout"}, opts.depthArgs()...)
+		if opts.Filter != "" {
+			args = append(args, "--filter="+opts.Filter)
+		}
+
+		if opts.Cache != "" {
+			if err := s.updateCache(ctx, opts.URL, opts.Cache); err != nil {
+				return err
+			}
+
+			args = append(args, "--reference", opts.Cache)
+		}
+
+		out, err := s.runner.RunWithTTY(ctx, "git", append(args, opts.URL, s.targetPath)...)
 		if err != nil {
-			return fmt.Errorf("failed to clone: %s\n%s\n%w", out, gitConfigHint(url), err)
+			return fmt.Errorf("failed to clone: %s\n%s\n%w", out, gitConfigHint(opts.URL), err)
 		}
 	}
 
-	if len(sparseCheckout) > 0 {
+	if len(opts.SparseCheckout) > 0 {
 		out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "sparse-checkout", "init", "--cone")
 		if err != nil {
 			return fmt.Errorf("failed to init sparse-checkout: %s %w", out, err)
@@ -100,7 +215,7 @@ func (s *svc) Sync(ctx context.Context, url, branch string, sparseCheckout []str
 		out, err = s.runner.Run(
 			ctx,
 			"git",
-			append([]string{"-C", s.targetPath, "sparse-checkout", "set"}, sparseCheckout...)...)
+			append([]string{"-C", s.targetPath, "sparse-checkout", "set"}, opts.SparseCheckout...)...)
 		if err != nil {
 			return fmt.Errorf("failed to set sparse-checkout: %s %w", out, err)
 		}
@@ -111,6 +226,80 @@ func (s *svc) Sync(ctx context.Context, url, branch string, sparseCheckout []str
 		}
 	}
 
+	if err := s.checkout(ctx, opts); err != nil {
+		return err
+	}
+
+	if opts.Submodules {
+		if err := s.updateSubmodules(ctx, opts.SparseCheckout); err != nil {
+			return err
+		}
+	}
+
+	if opts.LFS {
+		if err := s.pullLFS(ctx, opts.SparseCheckout); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deepen extends the history of an existing shallow clone in place when the source asks for more history
+// than the clone has, so changing the depth never needs a fresh clone.
+func (s *svc) deepen(ctx context.Context, opts SyncOptions) error {
+	if !opts.FullHistory && opts.Depth <= 1 {
+		return nil
+	}
+
+	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return fmt.Errorf("failed to check shallow clone: %s %w", out, err)
+	}
+
+	if strings.TrimSpace(out) != "true" {
+		return nil
+	}
+
+	args := []string{"-C", s.targetPath, "fetch"}
+	if opts.FullHistory {
+		args = append(args, "--unshallow")
+	} else {
+		args = append(args, opts.depthArgs()...)
+	}
+
+	out, err = s.runner.RunWithTTY(ctx, "git", append(args, "origin")...)
+	if err != nil {
+		return fmt.Errorf("failed to deepen shallow clone: %s %w", out, err)
+	}
+
+	return nil
+}
+
+func (s *svc) checkout(ctx context.Context, opts SyncOptions) error {
+	if opts.Ref != "" {
+		return s.checkoutRef(ctx, opts.Ref, opts.depthArgs())
+	}
+
+	if opts.Commit != "" {
+		return s.checkoutCommit(ctx, opts.Commit, opts.depthArgs())
+	}
+
+	if opts.Tag != "" {
+		return s.checkoutTag(ctx, opts.Tag, opts.depthArgs())
+	}
+
+	branch := opts.Branch
+	if branch == "" {
+		// The clone starts on the default branch, but HEAD may have been moved by a checkout of another ref
+		out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+		if err != nil {
+			return fmt.Errorf("failed to get default branch: %s %w", out, err)
+		}
+
+		branch = strings.TrimPrefix(strings.TrimSpace(out), "origin/")
+	}
+
 	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "checkout", branch)
 	if err != nil {
 		return fmt.Errorf("failed to checkout: %s %w", out, err)
@@ -119,6 +308,627 @@ func (s *svc) Sync(ctx context.Context, url, branch string, sparseCheckout []str
 	return s.Pull(ctx)
 }
 
+// updateSubmodules checks out the recorded commits of the submodules. Local changes inside submodules are
+// discarded the same way as in the repository itself. With a sparse checkout only submodules inside the
+// checked out paths are updated, git would clone the others too.
+func (s *svc) updateSubmodules(ctx context.Context, sparseCheckout []string) error {
+	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "submodule", "sync", "--recursive")
+	if err != nil {
+		return fmt.Errorf("failed to sync submodules: %s %w", out, err)
+	}
+
+	args := []string{"-C", s.targetPath, "submodule", "update", "--init", "--recursive", "--force"}
+	if len(sparseCheckout) > 0 {
+		args = append(append(args, "--"), sparseCheckout...)
+	}
+
+	out, err = s.runner.RunWithTTY(ctx, "git", args...)
+	if err != nil {
+		return fmt.Errorf("failed to update submodules: %s %w", out, err)
+	}
+
+	return nil
+}
+
+// pullLFS downloads LFS objects and replaces the pointer files with them. With a sparse checkout only objects
+// of the checked out paths are downloaded.
+func (s *svc) pullLFS(ctx context.Context, sparseCheckout []string) error {
+	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "lfs", "install", "--local")
+	if err != nil {
+		return fmt.Errorf("failed to install git-lfs hooks: %s %w", out, err)
+	}
+
+	args := []string{"-C", s.targetPath, "lfs", "pull"}
+	if len(sparseCheckout) > 0 {
+		args = append(args, "--include", strings.Join(sparseCheckout, ","))
+	}
+
+	out, err = s.runner.RunWithTTY(ctx, "git", args...)
+	if err != nil {
+		return fmt.Errorf("failed to pull LFS objects: %s %w", out, err)
+	}
+
+	return nil
+}
+
+// checkoutCommit fetches the exact commit (it may be missing in a shallow clone) and detaches HEAD at it.
+func (s *svc) checkoutCommit(ctx context.Context, commit string, depthArgs []string) error {
+	args := append(append([]string{"-C", s.targetPath, "fetch"}, depthArgs...), "origin", commit)
+
+	out, err := s.runner.RunWithTTY(ctx, "git", args...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch commit %s: %s %w", commit, out, err)
+	}
+
+	out, err = s.runner.Run(ctx, "git", "-C", s.targetPath, "checkout", "--detach", commit)
+	if err != nil {
+		return fmt.Errorf("failed to checkout commit %s: %s %w", commit, out, err)
+	}
+
+	return s.markSynced(ctx)
+}
+
+// checkoutTag fetches the tag (a shallow clone only has the default branch) and detaches HEAD at it.
+// The tag is force-updated, so a tag moved in the remote repository is picked up on the next sync.
+func (s *svc) checkoutTag(ctx context.Context, tag string, depthArgs []string) error {
+	ref := "refs/tags/" + tag
+	args := append(append([]string{"-C", s.targetPath, "fetch"}, depthArgs...), "origin", "+"+ref+":"+ref)
+
+	out, err := s.runner.RunWithTTY(ctx, "git", args...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch tag %s: %s %w", tag, out, err)
+	}
+
+	out, err = s.runner.Run(ctx, "git", "-C", s.targetPath, "checkout", "--detach", ref)
+	if err != nil {
+		return fmt.Errorf("failed to checkout tag %s: %s %w", tag, out, err)
+	}
+
+	return s.markSynced(ctx)
+}
+
+// syncedRef points at the commit a detached checkout of the sync is at. A pinned commit or a fetched ref may be on
+// no remote-tracking branch, it must not be taken for unpushed work.
+const syncedRef = "refs/devbox/synced"
+
+// unpushedRevs select the commits of local branches and of the checked out HEAD that are not on any remote.
+var unpushedRevs = []string{"--branches", "HEAD", "--not", "--remotes", "--glob=refs/devbox/*"}
+
+// markSynced points syncedRef at the detached HEAD the sync checked out.
+func (s *svc) markSynced(ctx context.Context) error {
+	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "update-ref", syncedRef, "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to mark synced commit: %s %w", out, err)
+	}
+
+	return nil
+}
+
+// checkoutRef fetches a ref the clone doesn't track and checks it out. A branch is checked out at the remote tip
+// next to a remote-tracking ref, so its commits are not taken for unpushed work later. Any other ref, such as
+// refs/pull/1/head of a forge, is checked out in detached HEAD state.
+func (s *svc) checkoutRef(ctx context.Context, ref string, depthArgs []string) error {
+	branch, isBranch := strings.CutPrefix(ref, "refs/heads/")
+	if !strings.HasPrefix(ref, "refs/") {
+		branch, isBranch = ref, true
+	}
+
+	if !isBranch {
+		args := append(append([]string{"-C", s.targetPath, "fetch"}, depthArgs...), "origin", ref)
+
+		out, err := s.runner.RunWithTTY(ctx, "git", args...)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s: %s %w", ref, out, err)
+		}
+
+		out, err = s.runner.Run(ctx, "git", "-C", s.targetPath, "checkout", "--detach", "FETCH_HEAD")
+		if err != nil {
+			return fmt.Errorf("failed to checkout %s: %s %w", ref, out, err)
+		}
+
+		return s.markSynced(ctx)
+	}
+
+	remoteRef := "refs/remotes/origin/" + branch
+	args := append(
+		append([]string{"-C", s.targetPath, "fetch"}, depthArgs...), "origin", "+refs/heads/"+branch+":"+remoteRef)
+
+	out, err := s.runner.RunWithTTY(ctx, "git", args...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch branch %s: %s %w", branch, out, err)
+	}
+
+	out, err = s.runner.Run(ctx, "git", "-C", s.targetPath, "checkout", "-B", branch, remoteRef)
+	if err != nil {
+		return fmt.Errorf("failed to checkout branch %s: %s %w", branch, out, err)
+	}
+
+	return nil
+}
+
+// ResolveRef returns the commit a branch, tag or full ref points to in the remote repository. An empty ref
+// stands for the remote HEAD. Annotated tags are resolved to the tagged commit.
+func (s *svc) ResolveRef(ctx context.Context, url, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	out, err := s.runner.Run(ctx, "git", "ls-remote", url, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to list remote refs: %s\n%s\n%w", out, gitConfigHint(url), err)
+	}
+
+	refs := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		hash, name, ok := strings.Cut(line, "\t")
+		if ok {
+			refs[name] = hash
+		}
+	}
+
+	for _, name := range []string{ref, "refs/heads/" + ref, "refs/tags/" + ref} {
+		if hash, ok := refs[name+"^{}"]; ok {
+			return hash, nil
+		}
+
+		if hash, ok := refs[name]; ok {
+			return hash, nil
+		}
+	}
+
+	return "", fmt.Errorf("ref %q not found in %s", ref, url)
+}
+
+// GetLocalChanges returns the work that a sync would destroy: modified or untracked files and commits of local
+// branches or of a detached HEAD that are not on any remote. A directory that is not a repository has no local
+// changes.
+func (s *svc) GetLocalChanges(ctx context.Context) (*LocalChanges, error) {
+	changes := &LocalChanges{}
+	if _, err := os.Stat(filepath.Join(s.targetPath, ".git")); err != nil {
+		return changes, nil
+	}
+
+	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "status", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %s %w", out, err)
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) > 3 {
+			changes.Files = append(changes.Files, line[3:])
+		}
+	}
+
+	args := append(append([]string{"-C", s.targetPath, "log"}, unpushedRevs...), "--format=%h %s")
+
+	out, err = s.runner.Run(ctx, "git", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unpushed commits: %s %w", out, err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line != "" {
+			changes.Commits = append(changes.Commits, line)
+		}
+	}
+
+	return changes, nil
+}
+
+// HasChanges tells whether any of the paths, relative to the target path, is modified or untracked.
+func (s *svc) HasChanges(ctx context.Context, paths ...string) (bool, error) {
+	args := append([]string{"-C", s.targetPath, "status", "--porcelain", "--"}, paths...)
+
+	out, err := s.runner.Run(ctx, "git", args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to get status: %s %w", out, err)
+	}
+
+	return strings.TrimSpace(out) != "", nil
+}
+
+// Backup saves the local changes into dir, so they can be recovered after a sync. Files go to a patch that
+// can be applied with `git apply`, commits go to a bundle that can be fetched from. It returns the created files.
+func (s *svc) Backup(ctx context.Context, dir string, changes *LocalChanges) ([]string, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	files := []string{}
+
+	if len(changes.Files) > 0 {
+		// Stage everything, so untracked files end up in the patch too. The index is reset by the sync anyway.
+		out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "add", "--all")
+		if err != nil {
+			return nil, fmt.Errorf("failed to stage changes: %s %w", out, err)
+		}
+
+		patch := filepath.Join(dir, "changes.patch")
+		out, err = s.runner.Run(
+			ctx, "git", "-C", s.targetPath, "diff", "--cached", "--binary", "--output="+patch, "HEAD")
+		if err != nil {
+			return nil, fmt.Errorf("failed to save patch: %s %w", out, err)
+		}
+
+		files = append(files, patch)
+	}
+
+	if len(changes.Commits) > 0 {
+		bundle := filepath.Join(dir, "commits.bundle")
+		args := append([]string{"-C", s.targetPath, "bundle", "create", bundle}, unpushedRevs...)
+
+		out, err := s.runner.Run(ctx, "git", args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save commits: %s %w", out, err)
+		}
+
+		files = append(files, bundle)
+	}
+
+	return files, nil
+}
+
+// GetStatus returns the checked out ref, the dirtiness and the ahead/behind counts against the remote-tracking
+// branch as of the last fetch.
+func (s *svc) GetStatus(ctx context.Context) (*Status, error) {
+	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %s %w", out, err)
+	}
+
+	status := &Status{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		header, ok := strings.CutPrefix(line, "# ")
+		if !ok {
+			status.Dirty = status.Dirty || line != ""
+			continue
+		}
+
+		key, value, _ := strings.Cut(header, " ")
+		switch key {
+		case "branch.oid":
+			status.Commit = value
+		case "branch.head":
+			if value != "(detached)" {
+				status.Branch = value
+			}
+		case "branch.upstream":
+			status.Upstream = value
+		case "branch.ab":
+			if _, err := fmt.Sscanf(value, "+%d -%d", &status.Ahead, &status.Behind); err != nil {
+				return nil, fmt.Errorf("failed to parse ahead/behind counts %q: %w", value, err)
+			}
+		}
+	}
+
+	if status.Branch == "" {
+		out, err = s.runner.Run(ctx, "git", "-C", s.targetPath, "tag", "--points-at", "HEAD")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tags: %s %w", out, err)
+		}
+
+		status.Tag, _, _ = strings.Cut(strings.TrimSpace(out), "\n")
+	}
+
+	return status, nil
+}
+
+// GetChangelog returns the commits reachable from to but not from from, and the number of changed files.
+// A shallow clone only knows the commits it fetched, so older commits of the range may be missing.
+func (s *svc) GetChangelog(ctx context.Context, from, to string) (*Changelog, error) {
+	changelog := &Changelog{}
+
+	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "log", "--format=%h %s", from+".."+to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commits: %s %w", out, err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line != "" {
+			changelog.Commits = append(changelog.Commits, line)
+		}
+	}
+
+	out, err = s.runner.Run(ctx, "git", "-C", s.targetPath, "diff", "--name-only", from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changed files: %s %w", out, err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line != "" {
+			changelog.Files++
+		}
+	}
+
+	return changelog, nil
+}
+
+// GetLastFetch returns when the repository was last fetched from its remote. A repository that was never fetched
+// after the clone reports the date of its checked out commit, which is older than the clone.
+func (s *svc) GetLastFetch(ctx context.Context) (time.Time, error) {
+	// The target path may be a subdirectory of the repository or a worktree, git knows where the file is
+	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "rev-parse", "--git-path", "FETCH_HEAD")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to locate FETCH_HEAD: %s %w", out, err)
+	}
+
+	fetchHead := strings.TrimSpace(out)
+	if !filepath.IsAbs(fetchHead) {
+		fetchHead = filepath.Join(s.targetPath, fetchHead)
+	}
+
+	if info, err := os.Stat(fetchHead); err == nil {
+		return info.ModTime(), nil
+	}
+
+	out, err = s.runner.Run(ctx, "git", "-C", s.targetPath, "log", "-1", "--format=%ct")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get commit date: %s %w", out, err)
+	}
+
+	sec, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse commit date %q: %w", out, err)
+	}
+
+	return time.Unix(sec, 0), nil
+}
+
+// Fetch updates all remote-tracking branches and tags from origin.
+func (s *svc) Fetch(ctx context.Context) error {
+	out, err := s.runner.RunWithTTY(ctx, "git", "-C", s.targetPath, "fetch", "--prune", "--tags", "origin")
+	if err != nil {
+		return fmt.Errorf("failed to fetch: %s %w", out, err)
+	}
+
+	return nil
+}
+
+// ResetTo discards local changes and checks out rev. With a branch, the branch is reset to rev and checked out,
+// it tracks rev when that is a remote-tracking branch. Without a branch, HEAD is detached at rev.
+// Ignored files are kept.
+func (s *svc) ResetTo(ctx context.Context, branch, rev string) error {
+	if err := s.reset(ctx, false); err != nil {
+		return fmt.Errorf("failed to reset repo: %w", err)
+	}
+
+	args := []string{"-C", s.targetPath, "checkout", "--force", "--detach", rev}
+	if branch != "" {
+		args = []string{"-C", s.targetPath, "checkout", "--force", "-B", branch, rev}
+	}
+
+	out, err := s.runner.Run(ctx, "git", args...)
+	if err != nil {
+		return fmt.Errorf("failed to checkout %s: %s %w", rev, out, err)
+	}
+
+	return nil
+}
+
+// ResolveRevision returns the full hash of the commit a local revision (hash, branch, tag, HEAD~1...) points to.
+func (s *svc) ResolveRevision(ctx context.Context, rev string) (string, error) {
+	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("revision %q not found: %s %w", rev, out, err)
+	}
+
+	return strings.TrimSpace(out), nil
+}
+
+// GetTrackedBranch returns the remote branch the checked out branch tracks, or the default branch of the remote
+// when nothing is tracked, e.g. in detached HEAD state.
+func (s *svc) GetTrackedBranch(ctx context.Context) (string, error) {
+	out, err := s.runner.Run(
+		ctx, "git", "-C", s.targetPath, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	if err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(out), "origin/"), nil
+	}
+
+	out, err = s.runner.Run(ctx, "git", "-C", s.targetPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to get default branch: %s %w", out, err)
+	}
+
+	return strings.TrimPrefix(strings.TrimSpace(out), "origin/"), nil
+}
+
+// AddWorktree checks out the branch in a new worktree of the repository at path. The branch is fetched from origin
+// first, shallow repositories fetch only what they miss. A local branch that is behind is fast-forwarded and one
+// that has diverged is refused, a branch that is only local or can't be fetched is used as it is. A branch created
+// here is deleted by RemoveWorktree again, unless it got commits of its own.
+func (s *svc) AddWorktree(ctx context.Context, path, branch string) error {
+	out, err := s.runner.Run(ctx, "git", "check-ref-format", "--branch", branch)
+	if err != nil {
+		return fmt.Errorf("invalid branch %q: %s %w", branch, out, err)
+	}
+
+	localRef := "refs/heads/" + branch
+	remoteRef := "refs/remotes/origin/" + branch
+
+	_, err = s.runner.Run(ctx, "git", "-C", s.targetPath, "rev-parse", "--verify", "--quiet", localRef)
+	if err == nil {
+		if err := s.fetchBranch(ctx, branch, localRef); err == nil {
+			if err := s.updateBranch(ctx, branch, localRef, remoteRef); err != nil {
+				return err
+			}
+		}
+
+		out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "worktree", "add", path, branch)
+		if err != nil {
+			return fmt.Errorf("failed to add worktree: %s %w", out, err)
+		}
+
+		return nil
+	}
+
+	if err := s.fetchBranch(ctx, branch, ""); err != nil {
+		return err
+	}
+
+	out, err = s.runner.Run(ctx, "git", "-C", s.targetPath, "worktree", "add", "-b", branch, path, remoteRef)
+	if err != nil {
+		return fmt.Errorf("failed to add worktree: %s %w", out, err)
+	}
+
+	out, err = s.runner.Run(ctx, "git", "-C", s.targetPath, "config", createdBranchKey(branch), "true")
+	if err != nil {
+		return fmt.Errorf("failed to mark branch %s: %s %w", branch, out, err)
+	}
+
+	return nil
+}
+
+// createdBranchKey is the config key that marks a branch created by AddWorktree. Git removes it with the branch.
+func createdBranchKey(branch string) string {
+	return "branch." + branch + ".devboxWorktree"
+}
+
+// fetchBranch fetches the branch from origin into its remote-tracking branch. A shallow repository fetches only
+// the last commit, or the history back to the commit of since, so the two can be compared.
+func (s *svc) fetchBranch(ctx context.Context, branch, since string) error {
+	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return fmt.Errorf("failed to check shallow clone: %s %w", out, err)
+	}
+
+	args := []string{"-C", s.targetPath, "fetch"}
+	if strings.TrimSpace(out) == "true" && since == "" {
+		args = append(args, "--depth=1")
+	} else if strings.TrimSpace(out) == "true" {
+		out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "log", "-1", "--format=%ct", since)
+		if err != nil {
+			return fmt.Errorf("failed to get commit date: %s %w", out, err)
+		}
+
+		args = append(args, "--shallow-since=@"+strings.TrimSpace(out))
+	}
+
+	out, err = s.runner.RunWithTTY(ctx, "git",
+		append(args, "origin", "+refs/heads/"+branch+":refs/remotes/origin/"+branch)...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch branch %s: %s %w", branch, out, err)
+	}
+
+	return nil
+}
+
+// updateBranch fast-forwards the local branch to the remote-tracking one. A branch with commits of its own is kept
+// while the remote has nothing new for it.
+func (s *svc) updateBranch(ctx context.Context, branch, localRef, remoteRef string) error {
+	_, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "merge-base", "--is-ancestor", remoteRef, localRef)
+	if err == nil {
+		return nil
+	}
+
+	_, err = s.runner.Run(ctx, "git", "-C", s.targetPath, "merge-base", "--is-ancestor", localRef, remoteRef)
+	if err != nil {
+		return fmt.Errorf("branch %s has diverged from origin/%s, merge or rebase it first", branch, branch)
+	}
+
+	// Unlike update-ref, it refuses to move a branch checked out somewhere
+	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "branch", "--force", branch, remoteRef)
+	if err != nil {
+		return fmt.Errorf("failed to fast-forward branch %s: %s %w", branch, out, err)
+	}
+
+	return nil
+}
+
+// RemoveWorktree removes a worktree of the repository, local changes in it are discarded. A worktree that is
+// already gone from disk is only forgotten by the repository. The branch of the worktree is deleted when
+// AddWorktree created it and it has no commits that are on no remote.
+func (s *svc) RemoveWorktree(ctx context.Context, path string) error {
+	branch, err := s.worktreeBranch(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "worktree", "prune")
+		if err != nil {
+			return fmt.Errorf("failed to prune worktrees: %s %w", out, err)
+		}
+	} else {
+		out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "worktree", "remove", "--force", path)
+		if err != nil {
+			return fmt.Errorf("failed to remove worktree: %s %w", out, err)
+		}
+	}
+
+	if branch == "" {
+		return nil
+	}
+
+	return s.deleteCreatedBranch(ctx, branch)
+}
+
+// worktreeBranch returns the branch checked out in the worktree at path, or nothing for a detached worktree or
+// a path that is no worktree of the repository.
+func (s *svc) worktreeBranch(ctx context.Context, path string) (string, error) {
+	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "worktree", "list", "--porcelain")
+	if err != nil {
+		return "", fmt.Errorf("failed to list worktrees: %s %w", out, err)
+	}
+
+	for _, block := range strings.Split(out, "\n\n") {
+		worktree, branch := "", ""
+		for _, line := range strings.Split(block, "\n") {
+			if value, ok := strings.CutPrefix(line, "worktree "); ok {
+				worktree = value
+			} else if value, ok := strings.CutPrefix(line, "branch refs/heads/"); ok {
+				branch = value
+			}
+		}
+
+		if worktree != "" && realPath(worktree) == realPath(path) {
+			return branch, nil
+		}
+	}
+
+	return "", nil
+}
+
+// realPath resolves the symlinks of the path, git lists worktrees by their real paths. The part of the path that
+// doesn't exist anymore is kept as it is.
+func realPath(path string) string {
+	path = filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path
+	}
+
+	return filepath.Join(realPath(parent), filepath.Base(path))
+}
+
+// deleteCreatedBranch deletes a branch AddWorktree created, unless it has commits that are on no remote.
+func (s *svc) deleteCreatedBranch(ctx context.Context, branch string) error {
+	out, err := s.runner.Run(ctx, "git", "-C", s.targetPath, "config", "--get", createdBranchKey(branch))
+	if err != nil || strings.TrimSpace(out) != "true" {
+		return nil
+	}
+
+	out, err = s.runner.Run(ctx, "git", "-C", s.targetPath,
+		"log", "refs/heads/"+branch, "--not", "--remotes", "--format=%h")
+	if err != nil {
+		return fmt.Errorf("failed to get unpushed commits of branch %s: %s %w", branch, out, err)
+	}
+
+	if strings.TrimSpace(out) != "" {
+		return nil
+	}
+
+	out, err = s.runner.Run(ctx, "git", "-C", s.targetPath, "branch", "--delete", "--force", branch)
+	if err != nil {
+		return fmt.Errorf("failed to delete branch %s: %s %w", branch, out, err)
+	}
+
+	return nil
+}
+
 func (s *svc) Pull(ctx context.Context) error {
 	if err := s.reset(ctx, false); err != nil {
 		return fmt.Errorf("failed to reset repo: %w", err)
@@ -6,8 +6,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -242,7 +244,8 @@ func TestSyncWithExcludes(t *testing.T) {
 	runner.EXPECT().RunWithTTY(mock.Anything, "git", "-C", targetPath, "pull", "--rebase").Return("", nil)
 
 	s := &svc{targetPath: targetPath, runner: runner, excludes: []string{exclude}}
-	if err := s.Sync(context.Background(), "https://github.com/org/repo.git", "main", nil); err != nil {
+	opts := SyncOptions{URL: "https://github.com/org/repo.git", Branch: "main"}
+	if err := s.Sync(context.Background(), opts); err != nil {
 		t.Errorf("Sync() error = %v", err)
 	}
 }
@@ -478,6 +481,38 @@ func TestGetTopLevel(t *testing.T) {
 // SetLocalExclude tests
 // ============================================================================
 
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		err     error
+		want    string
+		wantErr bool
+	}{
+		{name: "linux", output: "git version 2.39.2\n", want: "2.39.2"},
+		{name: "macos", output: "git version 2.39.3 (Apple Git-146)\n", want: "2.39.3"},
+		{name: "not installed", err: errors.New("executable file not found"), wantErr: true},
+		{name: "unexpected output", output: "oops", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewMockCommandRunner(t)
+			runner.EXPECT().Run(mock.Anything, "git", "version").Return(tt.output, tt.err)
+
+			got, err := version(context.Background(), runner)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("version() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("version() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSetLocalExclude(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -554,6 +589,66 @@ func TestSetLocalExclude(t *testing.T) {
 	}
 }
 
+func TestAddLocalExclude(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		patterns []string
+		want     string
+	}{
+		{
+			name:     "creates exclude file",
+			patterns: []string{"/sources/", "/.env"},
+			want:     "/sources/\n/.env\n",
+		},
+		{
+			name:     "keeps existing patterns",
+			existing: "*.log\n/.env",
+			patterns: []string{"/sources/", "/.env"},
+			want:     "*.log\n/.env\n/sources/\n",
+		},
+		{
+			name:     "nothing missing",
+			existing: "/sources/\n/.env\n",
+			patterns: []string{"/sources/", "/.env"},
+			want:     "/sources/\n/.env\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			excludeFile := filepath.Join(dir, ".git", "info", "exclude")
+			if tt.existing != "" {
+				if err := os.MkdirAll(filepath.Dir(excludeFile), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(excludeFile, []byte(tt.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			runner := NewMockCommandRunner(t)
+			runner.EXPECT().
+				Run(mock.Anything, "git", "-C", dir, "rev-parse", "--git-path", "info/exclude").
+				Return(".git/info/exclude\n", nil)
+
+			svc := newSvcWithRunner(dir, runner)
+			if err := svc.AddLocalExclude(context.Background(), tt.patterns); err != nil {
+				t.Fatalf("AddLocalExclude() error = %v", err)
+			}
+
+			content, err := os.ReadFile(excludeFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("content = %q, want %q", string(content), tt.want)
+			}
+		})
+	}
+}
+
 // ============================================================================
 // Sync tests
 // ============================================================================
@@ -564,6 +659,15 @@ func TestSync(t *testing.T) {
 		setupDir       bool
 		setupGit       bool
 		sparseCheckout []string
+		tag            string
+		commit         string
+		ref            string
+		defaultBranch  bool // the source doesn't configure a branch
+		submodules     bool
+		lfs            bool
+		depth          int
+		fullHistory    bool
+		filter         string
 		setupMock      func(m *MockCommandRunner, targetPath string)
 		wantErr        bool
 		errContain     string
@@ -659,37 +763,1287 @@ func TestSync(t *testing.T) {
 			wantErr:    true,
 			errContain: "failed to checkout",
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			dir := t.TempDir()
-			targetPath := filepath.Join(dir, "repo")
-
-			if tt.setupDir {
-				_ = os.MkdirAll(targetPath, 0o755)
-			}
-			if tt.setupGit {
-				_ = os.MkdirAll(filepath.Join(targetPath, ".git"), 0o755)
-			}
-
-			runner := NewMockCommandRunner(t)
-			tt.setupMock(runner, targetPath)
-
-			svc := newSvcWithRunner(targetPath, runner)
-			err := svc.Sync(context.Background(), "https://github.com/org/repo.git", "main", tt.sparseCheckout)
-
-			if (err != nil) != tt.wantErr {
-				t.Errorf("Sync() error = %v, wantErr %v", err, tt.wantErr)
-			}
-
-			if tt.errContain != "" && err != nil {
-				if !strings.Contains(err.Error(), tt.errContain) {
-					t.Errorf("error %q should contain %q", err.Error(), tt.errContain)
-				}
-			}
-		})
-	}
+		{
+			name:           "pinned commit is fetched and detached",
+			setupDir:       true,
+			setupGit:       true,
+			sparseCheckout: nil,
+			commit:         "abc123",
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fdx").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "--depth", "1", "origin", "abc123").
+					Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "checkout", "--detach", "abc123").Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "update-ref", "refs/devbox/synced", "HEAD").
+					Return("", nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:           "pinned commit can't be fetched",
+			setupDir:       true,
+			setupGit:       true,
+			sparseCheckout: nil,
+			commit:         "abc123",
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fdx").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "--depth", "1", "origin", "abc123").
+					Return("not our ref", errors.New("exit status 128"))
+			},
+			wantErr:    true,
+			errContain: "failed to fetch commit abc123",
+		},
+		{
+			name:           "tag is fetched and detached",
+			setupDir:       true,
+			setupGit:       true,
+			sparseCheckout: nil,
+			tag:            "v1.2.0",
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fdx").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "--depth", "1", "origin",
+						"+refs/tags/v1.2.0:refs/tags/v1.2.0").
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "checkout", "--detach", "refs/tags/v1.2.0").
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "update-ref", "refs/devbox/synced", "HEAD").
+					Return("", nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:          "default branch is taken from the remote HEAD",
+			setupDir:      true,
+			setupGit:      true,
+			defaultBranch: true,
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fdx").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD").
+					Return("origin/trunk\n", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "checkout", "trunk").Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fd").Return("", nil)
+				m.EXPECT().RunWithTTY(mock.Anything, "git", "-C", targetPath, "pull", "--rebase").Return("", nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:     "overriding branch is fetched with a remote-tracking ref",
+			setupDir: true,
+			setupGit: true,
+			tag:      "v1.2.0",
+			ref:      "feature/login",
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fdx").Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "--depth", "1", "origin",
+						"+refs/heads/feature/login:refs/remotes/origin/feature/login").
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "checkout", "-B", "feature/login",
+						"refs/remotes/origin/feature/login").
+					Return("", nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:     "overriding pull request ref is detached",
+			setupDir: true,
+			setupGit: true,
+			commit:   "abc123",
+			ref:      "refs/pull/42/head",
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fdx").Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "--depth", "1", "origin",
+						"refs/pull/42/head").
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "checkout", "--detach", "FETCH_HEAD").
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "update-ref", "refs/devbox/synced", "HEAD").
+					Return("", nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:           "pinned commit wins over tag",
+			setupDir:       true,
+			setupGit:       true,
+			sparseCheckout: nil,
+			tag:            "v1.2.0",
+			commit:         "abc123",
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fdx").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "--depth", "1", "origin", "abc123").
+					Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "checkout", "--detach", "abc123").Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "update-ref", "refs/devbox/synced", "HEAD").
+					Return("", nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:           "tag can't be fetched",
+			setupDir:       true,
+			setupGit:       true,
+			sparseCheckout: nil,
+			tag:            "v9.9.9",
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fdx").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "--depth", "1", "origin",
+						"+refs/tags/v9.9.9:refs/tags/v9.9.9").
+					Return("couldn't find remote ref", errors.New("exit status 128"))
+			},
+			wantErr:    true,
+			errContain: "failed to fetch tag v9.9.9",
+		},
+		{
+			name:           "submodules of sparse paths are updated",
+			setupDir:       true,
+			setupGit:       true,
+			sparseCheckout: []string{"src"},
+			tag:            "v1.2.0",
+			submodules:     true,
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fdx").Return("", nil).Once()
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "init", "--cone").
+					Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "set", "src").Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "--depth", "1", "origin",
+						"+refs/tags/v1.2.0:refs/tags/v1.2.0").
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "checkout", "--detach", "refs/tags/v1.2.0").
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "update-ref", "refs/devbox/synced", "HEAD").
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "submodule", "sync", "--recursive").
+					Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "submodule", "update", "--init", "--recursive",
+						"--force", "--", "src").
+					Return("", nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:           "submodule update fails",
+			setupDir:       true,
+			setupGit:       true,
+			sparseCheckout: nil,
+			commit:         "abc123",
+			submodules:     true,
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fdx").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "--depth", "1", "origin", "abc123").
+					Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "checkout", "--detach", "abc123").Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "update-ref", "refs/devbox/synced", "HEAD").
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "submodule", "sync", "--recursive").
+					Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "submodule", "update", "--init", "--recursive",
+						"--force").
+					Return("repository not found", errors.New("exit status 1"))
+			},
+			wantErr:    true,
+			errContain: "failed to update submodules: repository not found",
+		},
+		{
+			name:           "LFS objects of sparse paths are pulled",
+			setupDir:       true,
+			setupGit:       true,
+			sparseCheckout: []string{"src", "fixtures"},
+			commit:         "abc123",
+			lfs:            true,
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().Run(mock.Anything, "git", "lfs", "version").Return("git-lfs/3.3.0", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fdx").Return("", nil).Once()
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "init", "--cone").
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "set", "src", "fixtures").
+					Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "--depth", "1", "origin", "abc123").
+					Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "checkout", "--detach", "abc123").Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "update-ref", "refs/devbox/synced", "HEAD").
+					Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "lfs", "install", "--local").Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "lfs", "pull", "--include", "src,fixtures").
+					Return("", nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:           "LFS is not installed",
+			setupDir:       true,
+			setupGit:       true,
+			sparseCheckout: nil,
+			lfs:            true,
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().
+					Run(mock.Anything, "git", "lfs", "version").
+					Return("git: 'lfs' is not a git command", errors.New("exit status 1"))
+			},
+			wantErr:    true,
+			errContain: "git-lfs is required by the source but is not available",
+		},
+		{
+			name:           "fresh clone with depth and filter",
+			setupDir:       false,
+			setupGit:       false,
+			sparseCheckout: nil,
+			depth:          50,
+			filter:         "blob:none",
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "clone", "--no-checkout", "--depth", "50", "--filter=blob:none",
+						"https://github.com/org/repo.git", targetPath).
+					Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "checkout", "main").Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fd").Return("", nil)
+				m.EXPECT().RunWithTTY(mock.Anything, "git", "-C", targetPath, "pull", "--rebase").Return("", nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:           "fresh clone with full history fetches commit without depth",
+			setupDir:       false,
+			setupGit:       false,
+			sparseCheckout: nil,
+			commit:         "abc123",
+			fullHistory:    true,
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "clone", "--no-checkout", "https://github.com/org/repo.git",
+						targetPath).
+					Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "origin", "abc123").
+					Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "checkout", "--detach", "abc123").Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "update-ref", "refs/devbox/synced", "HEAD").
+					Return("", nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:           "existing shallow clone is unshallowed in place",
+			setupDir:       true,
+			setupGit:       true,
+			sparseCheckout: nil,
+			tag:            "v1.2.0",
+			fullHistory:    true,
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fdx").Return("", nil).Once()
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "rev-parse", "--is-shallow-repository").
+					Return("true\n", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "--unshallow", "origin").
+					Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "origin",
+						"+refs/tags/v1.2.0:refs/tags/v1.2.0").
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "checkout", "--detach", "refs/tags/v1.2.0").
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "update-ref", "refs/devbox/synced", "HEAD").
+					Return("", nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:           "existing shallow clone is deepened in place",
+			setupDir:       true,
+			setupGit:       true,
+			sparseCheckout: nil,
+			commit:         "abc123",
+			depth:          20,
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fdx").Return("", nil).Once()
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "rev-parse", "--is-shallow-repository").
+					Return("true\n", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "--depth", "20", "origin").
+					Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "--depth", "20", "origin", "abc123").
+					Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "checkout", "--detach", "abc123").Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "update-ref", "refs/devbox/synced", "HEAD").
+					Return("", nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:           "existing full clone is not fetched again",
+			setupDir:       true,
+			setupGit:       true,
+			sparseCheckout: nil,
+			commit:         "abc123",
+			fullHistory:    true,
+			setupMock: func(m *MockCommandRunner, targetPath string) {
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "reset", "--hard").Return("", nil).Once()
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "clean", "-fdx").Return("", nil).Once()
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "rev-parse", "--is-shallow-repository").
+					Return("false\n", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "sparse-checkout", "disable").Return("", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", "-C", targetPath, "fetch", "origin", "abc123").
+					Return("", nil)
+				m.EXPECT().Run(mock.Anything, "git", "-C", targetPath, "checkout", "--detach", "abc123").Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", targetPath, "update-ref", "refs/devbox/synced", "HEAD").
+					Return("", nil)
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			targetPath := filepath.Join(dir, "repo")
+
+			if tt.setupDir {
+				_ = os.MkdirAll(targetPath, 0o755)
+			}
+			if tt.setupGit {
+				_ = os.MkdirAll(filepath.Join(targetPath, ".git"), 0o755)
+			}
+
+			runner := NewMockCommandRunner(t)
+			tt.setupMock(runner, targetPath)
+
+			branch := "main"
+			if tt.defaultBranch {
+				branch = ""
+			}
+
+			svc := newSvcWithRunner(targetPath, runner)
+			err := svc.Sync(context.Background(), SyncOptions{
+				URL:            "https://github.com/org/repo.git",
+				Branch:         branch,
+				Tag:            tt.tag,
+				Commit:         tt.commit,
+				Ref:            tt.ref,
+				SparseCheckout: tt.sparseCheckout,
+				Submodules:     tt.submodules,
+				LFS:            tt.lfs,
+				Depth:          tt.depth,
+				FullHistory:    tt.fullHistory,
+				Filter:         tt.filter,
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Sync() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.errContain != "" && err != nil {
+				if !strings.Contains(err.Error(), tt.errContain) {
+					t.Errorf("error %q should contain %q", err.Error(), tt.errContain)
+				}
+			}
+		})
+	}
+}
+
+// ============================================================================
+// ResolveRef tests
+// ============================================================================
+
+func TestResolveRef(t *testing.T) {
+	const lsRemote = "1111\tHEAD\n" +
+		"2222\trefs/heads/main\n" +
+		"3333\trefs/heads/feature/main\n" +
+		"4444\trefs/tags/v1.0.0\n" +
+		"5555\trefs/tags/v1.0.0^{}\n"
+
+	tests := []struct {
+		name    string
+		ref     string
+		lsRef   string
+		output  string
+		err     error
+		want    string
+		wantErr string
+	}{
+		{name: "default branch", ref: "", lsRef: "HEAD", output: lsRemote, want: "1111"},
+		{name: "branch", ref: "main", lsRef: "main", output: lsRemote, want: "2222"},
+		{name: "annotated tag is peeled", ref: "v1.0.0", lsRef: "v1.0.0", output: lsRemote, want: "5555"},
+		{
+			name:   "full ref",
+			ref:    "refs/heads/feature/main",
+			lsRef:  "refs/heads/feature/main",
+			output: lsRemote,
+			want:   "3333",
+		},
+		{name: "not found", ref: "dev", lsRef: "dev", output: "", wantErr: `ref "dev" not found`},
+		{
+			name:    "command fails",
+			ref:     "main",
+			lsRef:   "main",
+			output:  "fatal: repository not found",
+			err:     errors.New("exit status 128"),
+			wantErr: "failed to list remote refs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewMockCommandRunner(t)
+			runner.EXPECT().
+				Run(mock.Anything, "git", "ls-remote", "https://github.com/org/repo.git", tt.lsRef).
+				Return(tt.output, tt.err)
+
+			svc := newSvcWithRunner("/tmp/test", runner)
+			got, err := svc.ResolveRef(context.Background(), "https://github.com/org/repo.git", tt.ref)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ResolveRef() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ResolveRef() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("ResolveRef() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Local changes tests
+// ============================================================================
+
+func TestGetLocalChangesWithoutRepo(t *testing.T) {
+	runner := NewMockCommandRunner(t)
+
+	svc := newSvcWithRunner(t.TempDir(), runner)
+	changes, err := svc.GetLocalChanges(context.Background())
+	if err != nil {
+		t.Fatalf("GetLocalChanges() error = %v", err)
+	}
+
+	if !changes.IsEmpty() {
+		t.Errorf("GetLocalChanges() = %+v, want no changes", changes)
+	}
+}
+
+func TestHasChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   bool
+	}{
+		{name: "clean", output: "", want: false},
+		{name: "untracked", output: "?? devbox.lock\n", want: true},
+		{name: "modified", output: " M devbox.lock\n", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewMockCommandRunner(t)
+			runner.EXPECT().
+				Run(mock.Anything, "git", "-C", "/tmp/test", "status", "--porcelain", "--", "devbox.lock").
+				Return(tt.output, nil)
+
+			svc := newSvcWithRunner("/tmp/test", runner)
+			got, err := svc.HasChanges(context.Background(), "devbox.lock")
+			if err != nil {
+				t.Fatalf("HasChanges() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("HasChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		tags   string
+		want   *Status
+	}{
+		{
+			name: "clean branch behind",
+			output: "# branch.oid 3f9a1c7e\n# branch.head main\n# branch.upstream origin/main\n" +
+				"# branch.ab +0 -2\n",
+			want: &Status{Branch: "main", Commit: "3f9a1c7e", Upstream: "origin/main", Behind: 2},
+		},
+		{
+			name: "dirty branch ahead",
+			output: "# branch.oid 3f9a1c7e\n# branch.head feature\n# branch.upstream origin/feature\n" +
+				"# branch.ab +3 -0\n1 .M N... 100644 100644 100644 aaa bbb main.go\n? notes.txt\n",
+			want: &Status{Branch: "feature", Commit: "3f9a1c7e", Upstream: "origin/feature", Ahead: 3, Dirty: true},
+		},
+		{
+			name:   "detached on tag",
+			output: "# branch.oid 3f9a1c7e\n# branch.head (detached)\n",
+			tags:   "v1.4.2\nlatest\n",
+			want:   &Status{Tag: "v1.4.2", Commit: "3f9a1c7e"},
+		},
+		{
+			name:   "detached on commit",
+			output: "# branch.oid 3f9a1c7e\n# branch.head (detached)\n",
+			want:   &Status{Commit: "3f9a1c7e"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewMockCommandRunner(t)
+			runner.EXPECT().
+				Run(mock.Anything, "git", "-C", "/tmp/test", "status", "--porcelain=v2", "--branch").
+				Return(tt.output, nil)
+			if tt.want.Branch == "" {
+				runner.EXPECT().
+					Run(mock.Anything, "git", "-C", "/tmp/test", "tag", "--points-at", "HEAD").
+					Return(tt.tags, nil)
+			}
+
+			svc := newSvcWithRunner("/tmp/test", runner)
+			got, err := svc.GetStatus(context.Background())
+			if err != nil {
+				t.Fatalf("GetStatus() error = %v", err)
+			}
+
+			if *got != *tt.want {
+				t.Errorf("GetStatus() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetChangelog(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		diff string
+		want *Changelog
+	}{
+		{
+			name: "new commits",
+			log:  "def5678 Fix login\nabc9012 Add login\n",
+			diff: "auth/login.go\nauth/login_test.go\nREADME.md\n",
+			want: &Changelog{Commits: []string{"def5678 Fix login", "abc9012 Add login"}, Files: 3},
+		},
+		{
+			name: "no changes",
+			want: &Changelog{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewMockCommandRunner(t)
+			runner.EXPECT().
+				Run(mock.Anything, "git", "-C", "/tmp/test", "log", "--format=%h %s", "1111..2222").
+				Return(tt.log, nil)
+			runner.EXPECT().
+				Run(mock.Anything, "git", "-C", "/tmp/test", "diff", "--name-only", "1111", "2222").
+				Return(tt.diff, nil)
+
+			svc := newSvcWithRunner("/tmp/test", runner)
+			got, err := svc.GetChangelog(context.Background(), "1111", "2222")
+			if err != nil {
+				t.Fatalf("GetChangelog() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetChangelog() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetLastFetch(t *testing.T) {
+	t.Run("fetched", func(t *testing.T) {
+		dir := t.TempDir()
+		fetchHead := filepath.Join(dir, ".git", "FETCH_HEAD")
+		fetched := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		if err := os.MkdirAll(filepath.Dir(fetchHead), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fetchHead, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(fetchHead, fetched, fetched); err != nil {
+			t.Fatal(err)
+		}
+
+		runner := NewMockCommandRunner(t)
+		runner.EXPECT().
+			Run(mock.Anything, "git", "-C", dir, "rev-parse", "--git-path", "FETCH_HEAD").
+			Return(".git/FETCH_HEAD\n", nil)
+
+		svc := newSvcWithRunner(dir, runner)
+		got, err := svc.GetLastFetch(context.Background())
+		if err != nil || !got.Equal(fetched) {
+			t.Errorf("GetLastFetch() = %v, %v, want %v", got, err, fetched)
+		}
+	})
+
+	t.Run("fetched repository of subdirectory", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git not installed")
+		}
+
+		repo := t.TempDir()
+		if out, err := exec.Command("git", "-C", repo, "init", "-q").CombinedOutput(); err != nil {
+			t.Fatalf("git init: %v\n%s", err, out)
+		}
+
+		subdir := filepath.Join(repo, "environments", "shop")
+		if err := os.MkdirAll(subdir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		fetched := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		fetchHead := filepath.Join(repo, ".git", "FETCH_HEAD")
+		if err := os.WriteFile(fetchHead, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(fetchHead, fetched, fetched); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := New(subdir).GetLastFetch(context.Background())
+		if err != nil || !got.Equal(fetched) {
+			t.Errorf("GetLastFetch() = %v, %v, want %v", got, err, fetched)
+		}
+	})
+
+	t.Run("never fetched", func(t *testing.T) {
+		runner := NewMockCommandRunner(t)
+		runner.EXPECT().
+			Run(mock.Anything, "git", "-C", "/tmp/test", "rev-parse", "--git-path", "FETCH_HEAD").
+			Return(".git/FETCH_HEAD\n", nil)
+		runner.EXPECT().
+			Run(mock.Anything, "git", "-C", "/tmp/test", "log", "-1", "--format=%ct").
+			Return("1714557600\n", nil)
+
+		svc := newSvcWithRunner("/tmp/test", runner)
+		got, err := svc.GetLastFetch(context.Background())
+		if err != nil || got.Unix() != 1714557600 {
+			t.Errorf("GetLastFetch() = %v, %v, want the commit date", got, err)
+		}
+	})
+}
+
+// TestBackupRestoresLocalChanges runs real git to prove that the backup of a dirty clone with an unpushed
+// commit survives the reset made by Sync and can be applied back.
+func TestResetTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		branch   string
+		rev      string
+		checkout []interface{}
+	}{
+		{
+			name:     "branch",
+			branch:   "main",
+			rev:      "refs/remotes/origin/main",
+			checkout: []interface{}{"-C", "/tmp/test", "checkout", "--force", "-B", "main", "refs/remotes/origin/main"},
+		},
+		{
+			name:     "detached",
+			rev:      "3f9a1c7e5b2d",
+			checkout: []interface{}{"-C", "/tmp/test", "checkout", "--force", "--detach", "3f9a1c7e5b2d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewMockCommandRunner(t)
+			runner.EXPECT().Run(mock.Anything, "git", "-C", "/tmp/test", "reset", "--hard").Return("", nil)
+			runner.EXPECT().Run(mock.Anything, "git", "-C", "/tmp/test", "clean", "-fd").Return("", nil)
+			runner.EXPECT().Run(mock.Anything, "git", tt.checkout...).
+				Return("", nil)
+
+			svc := newSvcWithRunner("/tmp/test", runner)
+			if err := svc.ResetTo(context.Background(), tt.branch, tt.rev); err != nil {
+				t.Fatalf("ResetTo() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestResolveRevision(t *testing.T) {
+	runner := NewMockCommandRunner(t)
+	runner.EXPECT().
+		Run(mock.Anything, "git", "-C", "/tmp/test", "rev-parse", "--verify", "--quiet", "v1.2^{commit}").
+		Return("3f9a1c7e5b2d4a6f\n", nil)
+	runner.EXPECT().
+		Run(mock.Anything, "git", "-C", "/tmp/test", "rev-parse", "--verify", "--quiet", "nope^{commit}").
+		Return("", errors.New("exit status 1"))
+
+	svc := newSvcWithRunner("/tmp/test", runner)
+
+	got, err := svc.ResolveRevision(context.Background(), "v1.2")
+	if err != nil || got != "3f9a1c7e5b2d4a6f" {
+		t.Errorf("ResolveRevision() = %q, %v, want %q", got, err, "3f9a1c7e5b2d4a6f")
+	}
+
+	if _, err := svc.ResolveRevision(context.Background(), "nope"); err == nil {
+		t.Error("ResolveRevision() expected error for unknown revision")
+	}
+}
+
+func TestGetTrackedBranch(t *testing.T) {
+	upstreamArgs := []interface{}{"-C", "/tmp/test", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"}
+	defaultArgs := []interface{}{"-C", "/tmp/test", "symbolic-ref", "--short", "refs/remotes/origin/HEAD"}
+
+	tests := []struct {
+		name      string
+		setupMock func(m *MockCommandRunner)
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "upstream",
+			setupMock: func(m *MockCommandRunner) {
+				m.EXPECT().Run(mock.Anything, "git", upstreamArgs...).Return("origin/release\n", nil)
+			},
+			want: "release",
+		},
+		{
+			name: "detached falls back to default branch",
+			setupMock: func(m *MockCommandRunner) {
+				m.EXPECT().Run(mock.Anything, "git", upstreamArgs...).Return("", errors.New("no upstream"))
+				m.EXPECT().Run(mock.Anything, "git", defaultArgs...).Return("origin/main\n", nil)
+			},
+			want: "main",
+		},
+		{
+			name: "no default branch",
+			setupMock: func(m *MockCommandRunner) {
+				m.EXPECT().Run(mock.Anything, "git", upstreamArgs...).Return("", errors.New("no upstream"))
+				m.EXPECT().Run(mock.Anything, "git", defaultArgs...).Return("", errors.New("not a symbolic ref"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewMockCommandRunner(t)
+			tt.setupMock(runner)
+
+			svc := newSvcWithRunner("/tmp/test", runner)
+			got, err := svc.GetTrackedBranch(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetTrackedBranch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("GetTrackedBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddWorktree(t *testing.T) {
+	localBranch := []interface{}{
+		"-C", "/tmp/test", "rev-parse", "--verify", "--quiet", "refs/heads/feature/login",
+	}
+	fetchBranch := []interface{}{"origin", "+refs/heads/feature/login:refs/remotes/origin/feature/login"}
+	fetch := append([]interface{}{"-C", "/tmp/test", "fetch"}, fetchBranch...)
+	remoteInLocal := []interface{}{
+		"-C", "/tmp/test", "merge-base", "--is-ancestor",
+		"refs/remotes/origin/feature/login", "refs/heads/feature/login",
+	}
+	localInRemote := []interface{}{
+		"-C", "/tmp/test", "merge-base", "--is-ancestor",
+		"refs/heads/feature/login", "refs/remotes/origin/feature/login",
+	}
+
+	tests := []struct {
+		name      string
+		branch    string
+		setupMock func(m *MockCommandRunner)
+		wantErr   bool
+	}{
+		{
+			name:   "local branch up to date",
+			branch: "feature/login",
+			setupMock: func(m *MockCommandRunner) {
+				m.EXPECT().Run(mock.Anything, "git", localBranch...).Return("3f9a1c7e\n", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", "/tmp/test", "rev-parse", "--is-shallow-repository").
+					Return("false\n", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", fetch...).
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", remoteInLocal...).
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", "/tmp/test", "worktree", "add", "/tmp/wt", "feature/login").
+					Return("", nil)
+			},
+		},
+		{
+			name:   "local branch behind in shallow clone",
+			branch: "feature/login",
+			setupMock: func(m *MockCommandRunner) {
+				m.EXPECT().Run(mock.Anything, "git", localBranch...).Return("3f9a1c7e\n", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", "/tmp/test", "rev-parse", "--is-shallow-repository").
+					Return("true\n", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", "/tmp/test",
+						"log", "-1", "--format=%ct", "refs/heads/feature/login").
+					Return("1700000000\n", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", append([]interface{}{
+						"-C", "/tmp/test", "fetch", "--shallow-since=@1700000000",
+					}, fetchBranch...)...).
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", remoteInLocal...).
+					Return("", errors.New("exit status 1"))
+				m.EXPECT().
+					Run(mock.Anything, "git", localInRemote...).
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", "/tmp/test", "branch", "--force", "feature/login",
+						"refs/remotes/origin/feature/login").
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", "/tmp/test", "worktree", "add", "/tmp/wt", "feature/login").
+					Return("", nil)
+			},
+		},
+		{
+			name:   "local branch diverged",
+			branch: "feature/login",
+			setupMock: func(m *MockCommandRunner) {
+				m.EXPECT().Run(mock.Anything, "git", localBranch...).Return("3f9a1c7e\n", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", "/tmp/test", "rev-parse", "--is-shallow-repository").
+					Return("false\n", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", fetch...).
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", remoteInLocal...).
+					Return("", errors.New("exit status 1"))
+				m.EXPECT().
+					Run(mock.Anything, "git", localInRemote...).
+					Return("", errors.New("exit status 1"))
+			},
+			wantErr: true,
+		},
+		{
+			name:   "local branch not on origin",
+			branch: "feature/login",
+			setupMock: func(m *MockCommandRunner) {
+				m.EXPECT().Run(mock.Anything, "git", localBranch...).Return("3f9a1c7e\n", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", "/tmp/test", "rev-parse", "--is-shallow-repository").
+					Return("false\n", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git", fetch...).
+					Return("fatal: couldn't find remote ref", errors.New("exit status 128"))
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", "/tmp/test", "worktree", "add", "/tmp/wt", "feature/login").
+					Return("", nil)
+			},
+		},
+		{
+			name:   "remote branch in shallow clone",
+			branch: "feature/login",
+			setupMock: func(m *MockCommandRunner) {
+				m.EXPECT().Run(mock.Anything, "git", localBranch...).Return("", errors.New("exit status 1"))
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", "/tmp/test", "rev-parse", "--is-shallow-repository").
+					Return("true\n", nil)
+				m.EXPECT().
+					RunWithTTY(mock.Anything, "git",
+						append([]interface{}{"-C", "/tmp/test", "fetch", "--depth=1"}, fetchBranch...)...).
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", "/tmp/test", "worktree", "add", "-b", "feature/login", "/tmp/wt",
+						"refs/remotes/origin/feature/login").
+					Return("", nil)
+				m.EXPECT().
+					Run(mock.Anything, "git", "-C", "/tmp/test",
+						"config", "branch.feature/login.devboxWorktree", "true").
+					Return("", nil)
+			},
+		},
+		{
+			name:   "invalid branch",
+			branch: "--force",
+			setupMock: func(m *MockCommandRunner) {
+				m.EXPECT().
+					Run(mock.Anything, "git", "check-ref-format", "--branch", "--force").
+					Return("usage: git check-ref-format", errors.New("exit status 129"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewMockCommandRunner(t)
+			if tt.branch != "--force" {
+				runner.EXPECT().Run(mock.Anything, "git", "check-ref-format", "--branch", tt.branch).Return("", nil)
+			}
+			tt.setupMock(runner)
+
+			svc := newSvcWithRunner("/tmp/test", runner)
+			err := svc.AddWorktree(context.Background(), "/tmp/wt", tt.branch)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AddWorktree() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRemoveWorktree(t *testing.T) {
+	worktree := t.TempDir()
+	missing := filepath.Join(worktree, "missing")
+	list := "worktree /tmp/test\nHEAD 3f9a1c7e\nbranch refs/heads/main\n\n" +
+		"worktree " + worktree + "\nHEAD 3f9a1c7e\nbranch refs/heads/fix\n\n" +
+		"worktree " + missing + "\nHEAD 3f9a1c7e\ndetached\nprunable gitdir file points to non-existent location\n"
+
+	runner := NewMockCommandRunner(t)
+	runner.EXPECT().Run(mock.Anything, "git", "-C", "/tmp/test", "worktree", "list", "--porcelain").Return(list, nil)
+	runner.EXPECT().
+		Run(mock.Anything, "git", "-C", "/tmp/test", "worktree", "remove", "--force", worktree).
+		Return("", nil)
+	runner.EXPECT().
+		Run(mock.Anything, "git", "-C", "/tmp/test", "config", "--get", "branch.fix.devboxWorktree").
+		Return("true\n", nil)
+	runner.EXPECT().
+		Run(mock.Anything, "git", "-C", "/tmp/test", "log", "refs/heads/fix", "--not", "--remotes", "--format=%h").
+		Return("", nil)
+	runner.EXPECT().Run(mock.Anything, "git", "-C", "/tmp/test", "branch", "--delete", "--force", "fix").Return("", nil)
+	runner.EXPECT().Run(mock.Anything, "git", "-C", "/tmp/test", "worktree", "prune").Return("", nil)
+
+	svc := newSvcWithRunner("/tmp/test", runner)
+	if err := svc.RemoveWorktree(context.Background(), worktree); err != nil {
+		t.Errorf("RemoveWorktree() error = %v", err)
+	}
+
+	if err := svc.RemoveWorktree(context.Background(), missing); err != nil {
+		t.Errorf("RemoveWorktree() of missing worktree error = %v", err)
+	}
+}
+
+func TestWorktreeBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("GIT_CONFIG_SYSTEM", "/dev/null")
+	t.Setenv("GIT_AUTHOR_NAME", "t")
+	t.Setenv("GIT_AUTHOR_EMAIL", "t@t")
+	t.Setenv("GIT_COMMITTER_NAME", "t")
+	t.Setenv("GIT_COMMITTER_EMAIL", "t@t")
+
+	root := t.TempDir()
+	upstream := filepath.Join(root, "upstream")
+	clone := filepath.Join(root, "clone")
+	worktree := filepath.Join(root, "wt")
+	runGit := func(dir string, args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(dir, file string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(file+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(dir, "add", file)
+		runGit(dir, "commit", "-m", file)
+	}
+	hasBranch := func() bool {
+		return exec.Command("git", "-C", clone, "rev-parse", "--verify", "--quiet", "refs/heads/fix").Run() == nil
+	}
+
+	if err := os.MkdirAll(upstream, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(upstream, "init", "-b", "main")
+	commit(upstream, "main.go")
+	runGit(upstream, "branch", "fix")
+	runGit(root, "clone", "--depth=1", "file://"+upstream, clone)
+
+	svc := New(clone)
+	ctx := context.Background()
+
+	// A branch created for the worktree goes away with it
+	if err := svc.AddWorktree(ctx, worktree, "fix"); err != nil {
+		t.Fatalf("AddWorktree() error = %v", err)
+	}
+	if err := svc.RemoveWorktree(ctx, worktree); err != nil {
+		t.Fatalf("RemoveWorktree() error = %v", err)
+	}
+	if hasBranch() {
+		t.Errorf("RemoveWorktree() kept the branch it created")
+	}
+
+	// A stale local branch is fast-forwarded
+	runGit(clone, "branch", "fix", "origin/fix")
+	runGit(upstream, "checkout", "-q", "fix")
+	commit(upstream, "fix.go")
+	if err := svc.AddWorktree(ctx, worktree, "fix"); err != nil {
+		t.Fatalf("AddWorktree() of stale branch error = %v", err)
+	}
+	if got, want := runGit(worktree, "rev-parse", "HEAD"), runGit(upstream, "rev-parse", "fix"); got != want {
+		t.Errorf("AddWorktree() checked out %s, want %s", got, want)
+	}
+
+	// A branch devbox didn't create is kept
+	if err := svc.RemoveWorktree(ctx, worktree); err != nil {
+		t.Fatalf("RemoveWorktree() error = %v", err)
+	}
+	if !hasBranch() {
+		t.Errorf("RemoveWorktree() deleted a branch it didn't create")
+	}
+
+	// A diverged local branch is refused
+	runGit(clone, "checkout", "-q", "fix")
+	commit(clone, "local.go")
+	runGit(clone, "checkout", "-q", "main")
+	commit(upstream, "remote.go")
+	if err := svc.AddWorktree(ctx, worktree, "fix"); err == nil || !strings.Contains(err.Error(), "diverged") {
+		t.Errorf("AddWorktree() of diverged branch error = %v, want diverged", err)
+	}
+
+	// A created branch with commits of its own is kept
+	runGit(upstream, "branch", "new")
+	newWorktree := filepath.Join(root, "new")
+	if err := svc.AddWorktree(ctx, newWorktree, "new"); err != nil {
+		t.Fatalf("AddWorktree() error = %v", err)
+	}
+	commit(newWorktree, "wip.go")
+	if err := svc.RemoveWorktree(ctx, newWorktree); err != nil {
+		t.Fatalf("RemoveWorktree() error = %v", err)
+	}
+	if err := exec.Command("git", "-C", clone, "rev-parse", "--verify", "--quiet", "refs/heads/new").Run(); err != nil {
+		t.Errorf("RemoveWorktree() deleted a branch with unpushed commits")
+	}
+}
+
+func TestBackupRestoresLocalChanges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("GIT_CONFIG_SYSTEM", "/dev/null")
+	t.Setenv("GIT_AUTHOR_NAME", "t")
+	t.Setenv("GIT_AUTHOR_EMAIL", "t@t")
+	t.Setenv("GIT_COMMITTER_NAME", "t")
+	t.Setenv("GIT_COMMITTER_EMAIL", "t@t")
+
+	root := t.TempDir()
+	upstream := filepath.Join(root, "upstream")
+	clone := filepath.Join(root, "clone")
+	runGit := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	if err := os.MkdirAll(upstream, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(upstream, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	runGit(upstream, "init")
+	runGit(upstream, "add", ".")
+	runGit(upstream, "commit", "-m", "init")
+	runGit(root, "clone", upstream, clone)
+
+	if err := os.WriteFile(filepath.Join(clone, "local.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(clone, "add", ".")
+	runGit(clone, "commit", "-m", "local work")
+
+	if err := os.WriteFile(filepath.Join(clone, "main.go"), []byte("package main // edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(clone, "notes.txt"), []byte("todo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := New(clone)
+	changes, err := svc.GetLocalChanges(context.Background())
+	if err != nil {
+		t.Fatalf("GetLocalChanges() error = %v", err)
+	}
+
+	if len(changes.Files) != 2 || len(changes.Commits) != 1 || !strings.HasSuffix(changes.Commits[0], " local work") {
+		t.Fatalf("GetLocalChanges() = %+v, want 2 files and 1 commit", changes)
+	}
+
+	backupDir := filepath.Join(root, "backup")
+	files, err := svc.Backup(context.Background(), backupDir, changes)
+	if err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("Backup() = %v, want a patch and a bundle", files)
+	}
+
+	runGit(clone, "reset", "--hard")
+	runGit(clone, "clean", "-fdx")
+	runGit(clone, "apply", filepath.Join(backupDir, "changes.patch"))
+	runGit(clone, "bundle", "verify", filepath.Join(backupDir, "commits.bundle"))
+
+	content, err := os.ReadFile(filepath.Join(clone, "notes.txt"))
+	if err != nil || string(content) != "todo\n" {
+		t.Errorf("untracked file should be restored from the patch, got %q, err = %v", content, err)
+	}
+}
+
+func TestGetLocalChangesOfPinnedCheckout(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("GIT_CONFIG_SYSTEM", "/dev/null")
+	t.Setenv("GIT_AUTHOR_NAME", "t")
+	t.Setenv("GIT_AUTHOR_EMAIL", "t@t")
+	t.Setenv("GIT_COMMITTER_NAME", "t")
+	t.Setenv("GIT_COMMITTER_EMAIL", "t@t")
+
+	root := t.TempDir()
+	upstream := filepath.Join(root, "upstream")
+	clone := filepath.Join(root, "clone")
+	runGit := func(dir string, args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+
+		return strings.TrimSpace(string(out))
+	}
+
+	if err := os.MkdirAll(upstream, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	runGit(upstream, "init", "-b", "main")
+	runGit(upstream, "commit", "--allow-empty", "-m", "pinned")
+	pinned := runGit(upstream, "rev-parse", "HEAD")
+	runGit(upstream, "commit", "--allow-empty", "-m", "latest")
+
+	svc := New(clone)
+	if err := svc.Sync(context.Background(), SyncOptions{URL: "file://" + upstream, Commit: pinned}); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	changes, err := svc.GetLocalChanges(context.Background())
+	if err != nil {
+		t.Fatalf("GetLocalChanges() error = %v", err)
+	}
+
+	if !changes.IsEmpty() {
+		t.Fatalf("GetLocalChanges() = %+v, the pinned commit is not local work", changes)
+	}
+
+	runGit(clone, "commit", "--allow-empty", "-m", "work on pinned commit")
+
+	changes, err = svc.GetLocalChanges(context.Background())
+	if err != nil {
+		t.Fatalf("GetLocalChanges() error = %v", err)
+	}
+
+	if len(changes.Commits) != 1 || !strings.HasSuffix(changes.Commits[0], " work on pinned commit") {
+		t.Fatalf("GetLocalChanges() = %+v, want the commit on the detached HEAD", changes)
+	}
+
+	backupDir := filepath.Join(root, "backup")
+	if _, err := svc.Backup(context.Background(), backupDir, changes); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	runGit(clone, "bundle", "verify", filepath.Join(backupDir, "commits.bundle"))
 }
 
 // ============================================================================
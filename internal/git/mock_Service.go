@@ -4,6 +4,7 @@ package git
 
 import (
 	context "context"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -21,6 +22,161 @@ func (_m *MockService) EXPECT() *MockService_Expecter {
 	return &MockService_Expecter{mock: &_m.Mock}
 }
 
+// AddLocalExclude provides a mock function with given fields: ctx, patterns
+func (_m *MockService) AddLocalExclude(ctx context.Context, patterns []string) error {
+	ret := _m.Called(ctx, patterns)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddLocalExclude")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) error); ok {
+		r0 = rf(ctx, patterns)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_AddLocalExclude_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddLocalExclude'
+type MockService_AddLocalExclude_Call struct {
+	*mock.Call
+}
+
+// AddLocalExclude is a helper method to define mock.On call
+//   - ctx context.Context
+//   - patterns []string
+func (_e *MockService_Expecter) AddLocalExclude(ctx interface{}, patterns interface{}) *MockService_AddLocalExclude_Call {
+	return &MockService_AddLocalExclude_Call{Call: _e.mock.On("AddLocalExclude", ctx, patterns)}
+}
+
+func (_c *MockService_AddLocalExclude_Call) Run(run func(ctx context.Context, patterns []string)) *MockService_AddLocalExclude_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]string))
+	})
+	return _c
+}
+
+func (_c *MockService_AddLocalExclude_Call) Return(_a0 error) *MockService_AddLocalExclude_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_AddLocalExclude_Call) RunAndReturn(run func(context.Context, []string) error) *MockService_AddLocalExclude_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// AddWorktree provides a mock function with given fields: ctx, path, branch
+func (_m *MockService) AddWorktree(ctx context.Context, path string, branch string) error {
+	ret := _m.Called(ctx, path, branch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddWorktree")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, path, branch)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_AddWorktree_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddWorktree'
+type MockService_AddWorktree_Call struct {
+	*mock.Call
+}
+
+// AddWorktree is a helper method to define mock.On call
+//   - ctx context.Context
+//   - path string
+//   - branch string
+func (_e *MockService_Expecter) AddWorktree(ctx interface{}, path interface{}, branch interface{}) *MockService_AddWorktree_Call {
+	return &MockService_AddWorktree_Call{Call: _e.mock.On("AddWorktree", ctx, path, branch)}
+}
+
+func (_c *MockService_AddWorktree_Call) Run(run func(ctx context.Context, path string, branch string)) *MockService_AddWorktree_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_AddWorktree_Call) Return(_a0 error) *MockService_AddWorktree_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_AddWorktree_Call) RunAndReturn(run func(context.Context, string, string) error) *MockService_AddWorktree_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Backup provides a mock function with given fields: ctx, dir, changes
+func (_m *MockService) Backup(ctx context.Context, dir string, changes *LocalChanges) ([]string, error) {
+	ret := _m.Called(ctx, dir, changes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Backup")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *LocalChanges) ([]string, error)); ok {
+		return rf(ctx, dir, changes)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *LocalChanges) []string); ok {
+		r0 = rf(ctx, dir, changes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *LocalChanges) error); ok {
+		r1 = rf(ctx, dir, changes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_Backup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Backup'
+type MockService_Backup_Call struct {
+	*mock.Call
+}
+
+// Backup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dir string
+//   - changes *LocalChanges
+func (_e *MockService_Expecter) Backup(ctx interface{}, dir interface{}, changes interface{}) *MockService_Backup_Call {
+	return &MockService_Backup_Call{Call: _e.mock.On("Backup", ctx, dir, changes)}
+}
+
+func (_c *MockService_Backup_Call) Run(run func(ctx context.Context, dir string, changes *LocalChanges)) *MockService_Backup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(*LocalChanges))
+	})
+	return _c
+}
+
+func (_c *MockService_Backup_Call) Return(_a0 []string, _a1 error) *MockService_Backup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_Backup_Call) RunAndReturn(run func(context.Context, string, *LocalChanges) ([]string, error)) *MockService_Backup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Clone provides a mock function with given fields: ctx, url, branch
 func (_m *MockService) Clone(ctx context.Context, url string, branch string) error {
 	ret := _m.Called(ctx, url, branch)
@@ -33,60 +189,394 @@ func (_m *MockService) Clone(ctx context.Context, url string, branch string) err
 	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
 		r0 = rf(ctx, url, branch)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_Clone_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Clone'
+type MockService_Clone_Call struct {
+	*mock.Call
+}
+
+// Clone is a helper method to define mock.On call
+//   - ctx context.Context
+//   - url string
+//   - branch string
+func (_e *MockService_Expecter) Clone(ctx interface{}, url interface{}, branch interface{}) *MockService_Clone_Call {
+	return &MockService_Clone_Call{Call: _e.mock.On("Clone", ctx, url, branch)}
+}
+
+func (_c *MockService_Clone_Call) Run(run func(ctx context.Context, url string, branch string)) *MockService_Clone_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_Clone_Call) Return(_a0 error) *MockService_Clone_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_Clone_Call) RunAndReturn(run func(context.Context, string, string) error) *MockService_Clone_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Fetch provides a mock function with given fields: ctx
+func (_m *MockService) Fetch(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Fetch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_Fetch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Fetch'
+type MockService_Fetch_Call struct {
+	*mock.Call
+}
+
+// Fetch is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockService_Expecter) Fetch(ctx interface{}) *MockService_Fetch_Call {
+	return &MockService_Fetch_Call{Call: _e.mock.On("Fetch", ctx)}
+}
+
+func (_c *MockService_Fetch_Call) Run(run func(ctx context.Context)) *MockService_Fetch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockService_Fetch_Call) Return(_a0 error) *MockService_Fetch_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_Fetch_Call) RunAndReturn(run func(context.Context) error) *MockService_Fetch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetChangelog provides a mock function with given fields: ctx, from, to
+func (_m *MockService) GetChangelog(ctx context.Context, from string, to string) (*Changelog, error) {
+	ret := _m.Called(ctx, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetChangelog")
+	}
+
+	var r0 *Changelog
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*Changelog, error)); ok {
+		return rf(ctx, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *Changelog); ok {
+		r0 = rf(ctx, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Changelog)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_GetChangelog_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetChangelog'
+type MockService_GetChangelog_Call struct {
+	*mock.Call
+}
+
+// GetChangelog is a helper method to define mock.On call
+//   - ctx context.Context
+//   - from string
+//   - to string
+func (_e *MockService_Expecter) GetChangelog(ctx interface{}, from interface{}, to interface{}) *MockService_GetChangelog_Call {
+	return &MockService_GetChangelog_Call{Call: _e.mock.On("GetChangelog", ctx, from, to)}
+}
+
+func (_c *MockService_GetChangelog_Call) Run(run func(ctx context.Context, from string, to string)) *MockService_GetChangelog_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_GetChangelog_Call) Return(_a0 *Changelog, _a1 error) *MockService_GetChangelog_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_GetChangelog_Call) RunAndReturn(run func(context.Context, string, string) (*Changelog, error)) *MockService_GetChangelog_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetInfo provides a mock function with given fields: ctx
+func (_m *MockService) GetInfo(ctx context.Context) (*CommitInfo, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInfo")
+	}
+
+	var r0 *CommitInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*CommitInfo, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *CommitInfo); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*CommitInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_GetInfo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInfo'
+type MockService_GetInfo_Call struct {
+	*mock.Call
+}
+
+// GetInfo is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockService_Expecter) GetInfo(ctx interface{}) *MockService_GetInfo_Call {
+	return &MockService_GetInfo_Call{Call: _e.mock.On("GetInfo", ctx)}
+}
+
+func (_c *MockService_GetInfo_Call) Run(run func(ctx context.Context)) *MockService_GetInfo_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockService_GetInfo_Call) Return(_a0 *CommitInfo, _a1 error) *MockService_GetInfo_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_GetInfo_Call) RunAndReturn(run func(context.Context) (*CommitInfo, error)) *MockService_GetInfo_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetLastFetch provides a mock function with given fields: ctx
+func (_m *MockService) GetLastFetch(ctx context.Context) (time.Time, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLastFetch")
+	}
+
+	var r0 time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (time.Time, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) time.Time); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_GetLastFetch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLastFetch'
+type MockService_GetLastFetch_Call struct {
+	*mock.Call
+}
+
+// GetLastFetch is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockService_Expecter) GetLastFetch(ctx interface{}) *MockService_GetLastFetch_Call {
+	return &MockService_GetLastFetch_Call{Call: _e.mock.On("GetLastFetch", ctx)}
+}
+
+func (_c *MockService_GetLastFetch_Call) Run(run func(ctx context.Context)) *MockService_GetLastFetch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockService_GetLastFetch_Call) Return(_a0 time.Time, _a1 error) *MockService_GetLastFetch_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_GetLastFetch_Call) RunAndReturn(run func(context.Context) (time.Time, error)) *MockService_GetLastFetch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetLocalChanges provides a mock function with given fields: ctx
+func (_m *MockService) GetLocalChanges(ctx context.Context) (*LocalChanges, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLocalChanges")
+	}
+
+	var r0 *LocalChanges
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*LocalChanges, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *LocalChanges); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*LocalChanges)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_GetLocalChanges_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLocalChanges'
+type MockService_GetLocalChanges_Call struct {
+	*mock.Call
+}
+
+// GetLocalChanges is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockService_Expecter) GetLocalChanges(ctx interface{}) *MockService_GetLocalChanges_Call {
+	return &MockService_GetLocalChanges_Call{Call: _e.mock.On("GetLocalChanges", ctx)}
+}
+
+func (_c *MockService_GetLocalChanges_Call) Run(run func(ctx context.Context)) *MockService_GetLocalChanges_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockService_GetLocalChanges_Call) Return(_a0 *LocalChanges, _a1 error) *MockService_GetLocalChanges_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_GetLocalChanges_Call) RunAndReturn(run func(context.Context) (*LocalChanges, error)) *MockService_GetLocalChanges_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRemote provides a mock function with given fields: ctx
+func (_m *MockService) GetRemote(ctx context.Context) (string, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRemote")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (string, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
 	}
 
-	return r0
+	return r0, r1
 }
 
-// MockService_Clone_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Clone'
-type MockService_Clone_Call struct {
+// MockService_GetRemote_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRemote'
+type MockService_GetRemote_Call struct {
 	*mock.Call
 }
 
-// Clone is a helper method to define mock.On call
+// GetRemote is a helper method to define mock.On call
 //   - ctx context.Context
-//   - url string
-//   - branch string
-func (_e *MockService_Expecter) Clone(ctx interface{}, url interface{}, branch interface{}) *MockService_Clone_Call {
-	return &MockService_Clone_Call{Call: _e.mock.On("Clone", ctx, url, branch)}
+func (_e *MockService_Expecter) GetRemote(ctx interface{}) *MockService_GetRemote_Call {
+	return &MockService_GetRemote_Call{Call: _e.mock.On("GetRemote", ctx)}
 }
 
-func (_c *MockService_Clone_Call) Run(run func(ctx context.Context, url string, branch string)) *MockService_Clone_Call {
+func (_c *MockService_GetRemote_Call) Run(run func(ctx context.Context)) *MockService_GetRemote_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].(string))
+		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *MockService_Clone_Call) Return(_a0 error) *MockService_Clone_Call {
-	_c.Call.Return(_a0)
+func (_c *MockService_GetRemote_Call) Return(_a0 string, _a1 error) *MockService_GetRemote_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_Clone_Call) RunAndReturn(run func(context.Context, string, string) error) *MockService_Clone_Call {
+func (_c *MockService_GetRemote_Call) RunAndReturn(run func(context.Context) (string, error)) *MockService_GetRemote_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetInfo provides a mock function with given fields: ctx
-func (_m *MockService) GetInfo(ctx context.Context) (*CommitInfo, error) {
+// GetStatus provides a mock function with given fields: ctx
+func (_m *MockService) GetStatus(ctx context.Context) (*Status, error) {
 	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetInfo")
+		panic("no return value specified for GetStatus")
 	}
 
-	var r0 *CommitInfo
+	var r0 *Status
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) (*CommitInfo, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) (*Status, error)); ok {
 		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) *CommitInfo); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) *Status); ok {
 		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*CommitInfo)
+			r0 = ret.Get(0).(*Status)
 		}
 	}
 
@@ -99,40 +589,40 @@ func (_m *MockService) GetInfo(ctx context.Context) (*CommitInfo, error) {
 	return r0, r1
 }
 
-// MockService_GetInfo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInfo'
-type MockService_GetInfo_Call struct {
+// MockService_GetStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetStatus'
+type MockService_GetStatus_Call struct {
 	*mock.Call
 }
 
-// GetInfo is a helper method to define mock.On call
+// GetStatus is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockService_Expecter) GetInfo(ctx interface{}) *MockService_GetInfo_Call {
-	return &MockService_GetInfo_Call{Call: _e.mock.On("GetInfo", ctx)}
+func (_e *MockService_Expecter) GetStatus(ctx interface{}) *MockService_GetStatus_Call {
+	return &MockService_GetStatus_Call{Call: _e.mock.On("GetStatus", ctx)}
 }
 
-func (_c *MockService_GetInfo_Call) Run(run func(ctx context.Context)) *MockService_GetInfo_Call {
+func (_c *MockService_GetStatus_Call) Run(run func(ctx context.Context)) *MockService_GetStatus_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *MockService_GetInfo_Call) Return(_a0 *CommitInfo, _a1 error) *MockService_GetInfo_Call {
+func (_c *MockService_GetStatus_Call) Return(_a0 *Status, _a1 error) *MockService_GetStatus_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_GetInfo_Call) RunAndReturn(run func(context.Context) (*CommitInfo, error)) *MockService_GetInfo_Call {
+func (_c *MockService_GetStatus_Call) RunAndReturn(run func(context.Context) (*Status, error)) *MockService_GetStatus_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetRemote provides a mock function with given fields: ctx
-func (_m *MockService) GetRemote(ctx context.Context) (string, error) {
+// GetTopLevel provides a mock function with given fields: ctx
+func (_m *MockService) GetTopLevel(ctx context.Context) (string, error) {
 	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetRemote")
+		panic("no return value specified for GetTopLevel")
 	}
 
 	var r0 string
@@ -155,40 +645,40 @@ func (_m *MockService) GetRemote(ctx context.Context) (string, error) {
 	return r0, r1
 }
 
-// MockService_GetRemote_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRemote'
-type MockService_GetRemote_Call struct {
+// MockService_GetTopLevel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTopLevel'
+type MockService_GetTopLevel_Call struct {
 	*mock.Call
 }
 
-// GetRemote is a helper method to define mock.On call
+// GetTopLevel is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockService_Expecter) GetRemote(ctx interface{}) *MockService_GetRemote_Call {
-	return &MockService_GetRemote_Call{Call: _e.mock.On("GetRemote", ctx)}
+func (_e *MockService_Expecter) GetTopLevel(ctx interface{}) *MockService_GetTopLevel_Call {
+	return &MockService_GetTopLevel_Call{Call: _e.mock.On("GetTopLevel", ctx)}
 }
 
-func (_c *MockService_GetRemote_Call) Run(run func(ctx context.Context)) *MockService_GetRemote_Call {
+func (_c *MockService_GetTopLevel_Call) Run(run func(ctx context.Context)) *MockService_GetTopLevel_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *MockService_GetRemote_Call) Return(_a0 string, _a1 error) *MockService_GetRemote_Call {
+func (_c *MockService_GetTopLevel_Call) Return(_a0 string, _a1 error) *MockService_GetTopLevel_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_GetRemote_Call) RunAndReturn(run func(context.Context) (string, error)) *MockService_GetRemote_Call {
+func (_c *MockService_GetTopLevel_Call) RunAndReturn(run func(context.Context) (string, error)) *MockService_GetTopLevel_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetTopLevel provides a mock function with given fields: ctx
-func (_m *MockService) GetTopLevel(ctx context.Context) (string, error) {
+// GetTrackedBranch provides a mock function with given fields: ctx
+func (_m *MockService) GetTrackedBranch(ctx context.Context) (string, error) {
 	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetTopLevel")
+		panic("no return value specified for GetTrackedBranch")
 	}
 
 	var r0 string
@@ -211,30 +701,101 @@ func (_m *MockService) GetTopLevel(ctx context.Context) (string, error) {
 	return r0, r1
 }
 
-// MockService_GetTopLevel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTopLevel'
-type MockService_GetTopLevel_Call struct {
+// MockService_GetTrackedBranch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTrackedBranch'
+type MockService_GetTrackedBranch_Call struct {
 	*mock.Call
 }
 
-// GetTopLevel is a helper method to define mock.On call
+// GetTrackedBranch is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockService_Expecter) GetTopLevel(ctx interface{}) *MockService_GetTopLevel_Call {
-	return &MockService_GetTopLevel_Call{Call: _e.mock.On("GetTopLevel", ctx)}
+func (_e *MockService_Expecter) GetTrackedBranch(ctx interface{}) *MockService_GetTrackedBranch_Call {
+	return &MockService_GetTrackedBranch_Call{Call: _e.mock.On("GetTrackedBranch", ctx)}
 }
 
-func (_c *MockService_GetTopLevel_Call) Run(run func(ctx context.Context)) *MockService_GetTopLevel_Call {
+func (_c *MockService_GetTrackedBranch_Call) Run(run func(ctx context.Context)) *MockService_GetTrackedBranch_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *MockService_GetTopLevel_Call) Return(_a0 string, _a1 error) *MockService_GetTopLevel_Call {
+func (_c *MockService_GetTrackedBranch_Call) Return(_a0 string, _a1 error) *MockService_GetTrackedBranch_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_GetTopLevel_Call) RunAndReturn(run func(context.Context) (string, error)) *MockService_GetTopLevel_Call {
+func (_c *MockService_GetTrackedBranch_Call) RunAndReturn(run func(context.Context) (string, error)) *MockService_GetTrackedBranch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HasChanges provides a mock function with given fields: ctx, paths
+func (_m *MockService) HasChanges(ctx context.Context, paths ...string) (bool, error) {
+	_va := make([]interface{}, len(paths))
+	for _i := range paths {
+		_va[_i] = paths[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HasChanges")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) (bool, error)); ok {
+		return rf(ctx, paths...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) bool); ok {
+		r0 = rf(ctx, paths...)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, ...string) error); ok {
+		r1 = rf(ctx, paths...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_HasChanges_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HasChanges'
+type MockService_HasChanges_Call struct {
+	*mock.Call
+}
+
+// HasChanges is a helper method to define mock.On call
+//   - ctx context.Context
+//   - paths ...string
+func (_e *MockService_Expecter) HasChanges(ctx interface{}, paths ...interface{}) *MockService_HasChanges_Call {
+	return &MockService_HasChanges_Call{Call: _e.mock.On("HasChanges",
+		append([]interface{}{ctx}, paths...)...)}
+}
+
+func (_c *MockService_HasChanges_Call) Run(run func(ctx context.Context, paths ...string)) *MockService_HasChanges_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]string, len(args)-1)
+		for i, a := range args[1:] {
+			if a != nil {
+				variadicArgs[i] = a.(string)
+			}
+		}
+		run(args[0].(context.Context), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockService_HasChanges_Call) Return(_a0 bool, _a1 error) *MockService_HasChanges_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_HasChanges_Call) RunAndReturn(run func(context.Context, ...string) (bool, error)) *MockService_HasChanges_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -285,6 +846,216 @@ func (_c *MockService_Pull_Call) RunAndReturn(run func(context.Context) error) *
 	return _c
 }
 
+// RemoveWorktree provides a mock function with given fields: ctx, path
+func (_m *MockService) RemoveWorktree(ctx context.Context, path string) error {
+	ret := _m.Called(ctx, path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveWorktree")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, path)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_RemoveWorktree_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveWorktree'
+type MockService_RemoveWorktree_Call struct {
+	*mock.Call
+}
+
+// RemoveWorktree is a helper method to define mock.On call
+//   - ctx context.Context
+//   - path string
+func (_e *MockService_Expecter) RemoveWorktree(ctx interface{}, path interface{}) *MockService_RemoveWorktree_Call {
+	return &MockService_RemoveWorktree_Call{Call: _e.mock.On("RemoveWorktree", ctx, path)}
+}
+
+func (_c *MockService_RemoveWorktree_Call) Run(run func(ctx context.Context, path string)) *MockService_RemoveWorktree_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_RemoveWorktree_Call) Return(_a0 error) *MockService_RemoveWorktree_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_RemoveWorktree_Call) RunAndReturn(run func(context.Context, string) error) *MockService_RemoveWorktree_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResetTo provides a mock function with given fields: ctx, branch, rev
+func (_m *MockService) ResetTo(ctx context.Context, branch string, rev string) error {
+	ret := _m.Called(ctx, branch, rev)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetTo")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, branch, rev)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_ResetTo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResetTo'
+type MockService_ResetTo_Call struct {
+	*mock.Call
+}
+
+// ResetTo is a helper method to define mock.On call
+//   - ctx context.Context
+//   - branch string
+//   - rev string
+func (_e *MockService_Expecter) ResetTo(ctx interface{}, branch interface{}, rev interface{}) *MockService_ResetTo_Call {
+	return &MockService_ResetTo_Call{Call: _e.mock.On("ResetTo", ctx, branch, rev)}
+}
+
+func (_c *MockService_ResetTo_Call) Run(run func(ctx context.Context, branch string, rev string)) *MockService_ResetTo_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_ResetTo_Call) Return(_a0 error) *MockService_ResetTo_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_ResetTo_Call) RunAndReturn(run func(context.Context, string, string) error) *MockService_ResetTo_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResolveRef provides a mock function with given fields: ctx, url, ref
+func (_m *MockService) ResolveRef(ctx context.Context, url string, ref string) (string, error) {
+	ret := _m.Called(ctx, url, ref)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResolveRef")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return rf(ctx, url, ref)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, url, ref)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, url, ref)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_ResolveRef_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResolveRef'
+type MockService_ResolveRef_Call struct {
+	*mock.Call
+}
+
+// ResolveRef is a helper method to define mock.On call
+//   - ctx context.Context
+//   - url string
+//   - ref string
+func (_e *MockService_Expecter) ResolveRef(ctx interface{}, url interface{}, ref interface{}) *MockService_ResolveRef_Call {
+	return &MockService_ResolveRef_Call{Call: _e.mock.On("ResolveRef", ctx, url, ref)}
+}
+
+func (_c *MockService_ResolveRef_Call) Run(run func(ctx context.Context, url string, ref string)) *MockService_ResolveRef_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_ResolveRef_Call) Return(_a0 string, _a1 error) *MockService_ResolveRef_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_ResolveRef_Call) RunAndReturn(run func(context.Context, string, string) (string, error)) *MockService_ResolveRef_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResolveRevision provides a mock function with given fields: ctx, rev
+func (_m *MockService) ResolveRevision(ctx context.Context, rev string) (string, error) {
+	ret := _m.Called(ctx, rev)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResolveRevision")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, rev)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, rev)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, rev)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_ResolveRevision_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResolveRevision'
+type MockService_ResolveRevision_Call struct {
+	*mock.Call
+}
+
+// ResolveRevision is a helper method to define mock.On call
+//   - ctx context.Context
+//   - rev string
+func (_e *MockService_Expecter) ResolveRevision(ctx interface{}, rev interface{}) *MockService_ResolveRevision_Call {
+	return &MockService_ResolveRevision_Call{Call: _e.mock.On("ResolveRevision", ctx, rev)}
+}
+
+func (_c *MockService_ResolveRevision_Call) Run(run func(ctx context.Context, rev string)) *MockService_ResolveRevision_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_ResolveRevision_Call) Return(_a0 string, _a1 error) *MockService_ResolveRevision_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_ResolveRevision_Call) RunAndReturn(run func(context.Context, string) (string, error)) *MockService_ResolveRevision_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // SetLocalExclude provides a mock function with given fields: patterns
 func (_m *MockService) SetLocalExclude(patterns []string) error {
 	ret := _m.Called(patterns)
@@ -331,17 +1102,17 @@ func (_c *MockService_SetLocalExclude_Call) RunAndReturn(run func([]string) erro
 	return _c
 }
 
-// Sync provides a mock function with given fields: ctx, url, branch, sparseCheckout
-func (_m *MockService) Sync(ctx context.Context, url string, branch string, sparseCheckout []string) error {
-	ret := _m.Called(ctx, url, branch, sparseCheckout)
+// Sync provides a mock function with given fields: ctx, opts
+func (_m *MockService) Sync(ctx context.Context, opts SyncOptions) error {
+	ret := _m.Called(ctx, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Sync")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string) error); ok {
-		r0 = rf(ctx, url, branch, sparseCheckout)
+	if rf, ok := ret.Get(0).(func(context.Context, SyncOptions) error); ok {
+		r0 = rf(ctx, opts)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -356,16 +1127,14 @@ type MockService_Sync_Call struct {
 
 // Sync is a helper method to define mock.On call
 //   - ctx context.Context
-//   - url string
-//   - branch string
-//   - sparseCheckout []string
-func (_e *MockService_Expecter) Sync(ctx interface{}, url interface{}, branch interface{}, sparseCheckout interface{}) *MockService_Sync_Call {
-	return &MockService_Sync_Call{Call: _e.mock.On("Sync", ctx, url, branch, sparseCheckout)}
+//   - opts SyncOptions
+func (_e *MockService_Expecter) Sync(ctx interface{}, opts interface{}) *MockService_Sync_Call {
+	return &MockService_Sync_Call{Call: _e.mock.On("Sync", ctx, opts)}
 }
 
-func (_c *MockService_Sync_Call) Run(run func(ctx context.Context, url string, branch string, sparseCheckout []string)) *MockService_Sync_Call {
+func (_c *MockService_Sync_Call) Run(run func(ctx context.Context, opts SyncOptions)) *MockService_Sync_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].([]string))
+		run(args[0].(context.Context), args[1].(SyncOptions))
 	})
 	return _c
 }
@@ -375,7 +1144,7 @@ func (_c *MockService_Sync_Call) Return(_a0 error) *MockService_Sync_Call {
 	return _c
 }
 
-func (_c *MockService_Sync_Call) RunAndReturn(run func(context.Context, string, string, []string) error) *MockService_Sync_Call {
+func (_c *MockService_Sync_Call) RunAndReturn(run func(context.Context, SyncOptions) error) *MockService_Sync_Call {
 	_c.Call.Return(run)
 	return _c
 }
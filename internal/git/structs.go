@@ -1,8 +1,62 @@
 package git
 
+import "strconv"
+
 type CommitInfo struct {
 	Hash    string
 	Author  string
 	Date    string
 	Message string
 }
+
+// LocalChanges describes the work in a repository that is not pushed anywhere.
+type LocalChanges struct {
+	Files   []string // modified and untracked files, relative to the repository root
+	Commits []string // commits of local branches missing on the remotes, as "<short hash> <subject>"
+}
+
+func (c *LocalChanges) IsEmpty() bool {
+	return len(c.Files) == 0 && len(c.Commits) == 0
+}
+
+// Changelog describes the commits between two revisions of a repository.
+type Changelog struct {
+	Commits []string // commits as "<short hash> <subject>", newest first
+	Files   int      // number of files that differ between the revisions
+}
+
+// Status describes the checkout of a repository compared to its remote-tracking branch.
+type Status struct {
+	Branch   string // checked out branch, empty in detached HEAD state
+	Tag      string // tag pointing at the detached HEAD, if any
+	Commit   string
+	Upstream string // remote-tracking branch, empty when the checkout has none
+	Ahead    int    // commits missing on the upstream
+	Behind   int    // commits of the upstream missing locally
+	Dirty    bool   // modified or untracked files are present
+}
+
+// SyncOptions describes the checkout that Sync should produce.
+type SyncOptions struct {
+	URL            string
+	Branch         string
+	Tag            string // tag to check out instead of the branch tip
+	Commit         string // exact commit to check out, takes precedence over the tag and the branch
+	Ref            string // branch or full ref (e.g. refs/pull/1/head) to check out instead of all of the above
+	SparseCheckout []string
+	Submodules     bool   // initialize and update submodules recursively
+	LFS            bool   // download Git LFS objects of the checked out files
+	Depth          int    // number of commits to fetch, defaults to 1
+	FullHistory    bool   // fetch the whole history instead of Depth commits
+	Filter         string // partial clone filter for new clones, e.g. "blob:none"
+	Cache          string // shared bare repository that new clones borrow objects from
+}
+
+// depthArgs returns the --depth flag for clone and fetch, nothing when the full history is wanted.
+func (o SyncOptions) depthArgs() []string {
+	if o.FullHistory {
+		return nil
+	}
+
+	return []string{"--depth", strconv.Itoa(max(o.Depth, 1))}
+}
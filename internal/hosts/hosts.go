@@ -21,6 +21,45 @@ func Save(projectName string, entries []string) (bool, error) {
 	return save(defaultHostFile, projectName, entries)
 }
 
+// Read returns the entries of the project block in the hosts file, nil when there is no block.
+func Read(projectName string) ([]string, error) {
+	return read(defaultHostFile, projectName)
+}
+
+func read(hostFile, projectName string) ([]string, error) {
+	markerBegin := fmt.Sprintf(beginMarkerTemplate, projectName)
+	markerEnd := fmt.Sprintf(endMarkerTemplate, projectName)
+
+	content, err := os.ReadFile(hostFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hosts file: %w", err)
+	}
+
+	var entries []string
+	inBlock := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == markerBegin:
+			inBlock = true
+			entries = []string{}
+		case line == markerEnd && inBlock:
+			return entries, nil
+		case inBlock:
+			entries = append(entries, line)
+		}
+	}
+
+	if inBlock {
+		return nil, errors.New("unexpected end of file")
+	}
+
+	return nil, nil
+}
+
 func save(hostFile, projectName string, entries []string) (bool, error) {
 	markerBegin := fmt.Sprintf(beginMarkerTemplate, projectName)
 	markerEnd := fmt.Sprintf(endMarkerTemplate, projectName)
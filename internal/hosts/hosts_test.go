@@ -59,3 +59,47 @@ func TestSave(t *testing.T) {
 		})
 	}
 }
+
+func TestRead(t *testing.T) {
+	tt := []struct {
+		name            string
+		content         string
+		expectedEntries []string
+		expectedErr     bool
+	}{
+		{
+			"File with project block",
+			"127.0.0.1 localhost\n# BEGIN: Devbox 'test-project' project\n127.0.0.2 testhost\n# END: Devbox: 'test-project' project\n",
+			[]string{"127.0.0.2 testhost"},
+			false,
+		},
+		{
+			"File with other project block",
+			"127.0.0.1 localhost\n# BEGIN: Devbox 'test-project-2' project\n127.0.0.2 testhost\n# END: Devbox: 'test-project-2' project\n",
+			nil,
+			false,
+		},
+		{
+			"Unterminated block",
+			"# BEGIN: Devbox 'test-project' project\n127.0.0.2 testhost\n",
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tempFile, err := os.CreateTemp("", "hosts")
+			assert.NoError(t, err)
+			defer func() { _ = os.Remove(tempFile.Name()) }()
+
+			_, err = tempFile.WriteString(tc.content)
+			assert.NoError(t, err)
+			_ = tempFile.Close()
+
+			entries, err := read(tempFile.Name(), "test-project")
+			assert.Equal(t, tc.expectedErr, err != nil)
+			assert.Equal(t, tc.expectedEntries, entries)
+		})
+	}
+}
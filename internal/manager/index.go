@@ -0,0 +1,186 @@
+package manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pilat/devbox/internal/app"
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/project"
+)
+
+// indexVersion changes together with indexEntry, an index of another version is rebuilt
+const indexVersion = 1
+
+// indexEntry is what autodetection needs to know about a project. It is kept in the index file, so the compose
+// files of a project are parsed only after they changed.
+type indexEntry struct {
+	Name        string            `json:"name"`
+	WorkingDir  string            `json:"workingDir"`
+	SourceURLs  []string          `json:"sourceUrls"` // normalized
+	LocalMounts map[string]string `json:"localMounts"`
+	ManifestURL string            `json:"manifestUrl,omitempty"` // normalized, looked up on first use
+	Fingerprint string            `json:"fingerprint"`           // empty when the project can't be indexed
+}
+
+type indexFile struct {
+	Version  int                    `json:"version"`
+	Projects map[string]*indexEntry `json:"projects"`
+}
+
+func newIndexEntry(p *project.Project) *indexEntry {
+	sourceURLs := make([]string, 0, len(p.Sources))
+	for _, source := range p.Sources {
+		if source.URL != "" {
+			sourceURLs = append(sourceURLs, git.NormalizeURL(source.URL))
+		}
+	}
+
+	sort.Strings(sourceURLs)
+
+	localMounts := make(map[string]string, len(p.LocalMounts))
+	for k, v := range p.LocalMounts {
+		localMounts[k] = v
+	}
+
+	return &indexEntry{
+		Name:        p.Name,
+		WorkingDir:  p.WorkingDir,
+		SourceURLs:  sourceURLs,
+		LocalMounts: localMounts,
+	}
+}
+
+// readIndex returns the index entries of all projects. Projects that changed since they were indexed are loaded
+// and indexed again, they are returned as well so they are never loaded twice.
+func (m *Manager) readIndex(ctx context.Context) ([]*indexEntry, map[string]*project.Project, error) {
+	projectNames, err := m.listFn("")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	index := m.loadIndexFile()
+
+	entries := make([]*indexEntry, 0, len(projectNames))
+	loaded := map[string]*project.Project{}
+	changed := len(index.Projects) != len(projectNames)
+
+	for _, projectName := range projectNames {
+		// Taken before loading: a change made while the project loads makes the entry stale, not wrong
+		fingerprint, fingerprintErr := fingerprintProject(filepath.Join(app.AppDir, projectName))
+
+		entry, ok := index.Projects[projectName]
+		if ok && fingerprintErr == nil && entry.Fingerprint == fingerprint {
+			entries = append(entries, entry)
+			continue
+		}
+
+		p, err := m.loadFn(ctx, projectName, []string{"*"})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load project: %w", err)
+		}
+
+		entry = newIndexEntry(p)
+		if fingerprintErr == nil {
+			entry.Fingerprint = fingerprint
+		}
+
+		entries = append(entries, entry)
+		loaded[projectName] = p
+		changed = true
+	}
+
+	if changed {
+		m.saveIndex(entries)
+	}
+
+	return entries, loaded, nil
+}
+
+// loadIndexFile returns an empty index when there is no usable index file.
+func (m *Manager) loadIndexFile() *indexFile {
+	index := &indexFile{}
+
+	data, err := os.ReadFile(m.indexPath)
+	if err == nil {
+		_ = json.Unmarshal(data, index)
+	}
+
+	if index.Version != indexVersion || index.Projects == nil {
+		return &indexFile{Version: indexVersion, Projects: map[string]*indexEntry{}}
+	}
+
+	return index
+}
+
+// saveIndex writes the entries that can be indexed. The index is only a cache, failing to write it just makes the
+// next command load the projects again.
+func (m *Manager) saveIndex(entries []*indexEntry) {
+	index := &indexFile{Version: indexVersion, Projects: map[string]*indexEntry{}}
+	for _, entry := range entries {
+		if entry.Fingerprint != "" {
+			index.Projects[entry.Name] = entry
+		}
+	}
+
+	if len(index.Projects) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return
+	}
+
+	// Written aside and renamed, so concurrent commands never read a partial index
+	tmp := fmt.Sprintf("%s.%d", m.indexPath, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return
+	}
+
+	if err := os.Rename(tmp, m.indexPath); err != nil {
+		_ = os.Remove(tmp)
+	}
+}
+
+// fingerprintProject hashes the sizes and modification times of the files a project is loaded from: the files at
+// the top of the project directory (compose, env, state and lock files) and the git files that change with
+// checkouts and remotes.
+func fingerprintProject(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read project directory: %w", err)
+	}
+
+	h := sha256.New()
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return "", fmt.Errorf("failed to get file info: %w", err)
+		}
+
+		fmt.Fprintf(h, "%s %d %d\n", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+
+	for _, name := range []string{"HEAD", "config", "index"} {
+		info, err := os.Stat(filepath.Join(dir, ".git", name))
+		if err != nil {
+			continue
+		}
+
+		fmt.Fprintf(h, ".git/%s %d %d\n", name, info.Size(), info.ModTime().UnixNano())
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
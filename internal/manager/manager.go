@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
@@ -36,6 +38,7 @@ type gitServiceFactory func(path string) git.Service
 type Manager struct {
 	gitFactory gitServiceFactory
 	fs         fs.FileSystem
+	indexPath  string
 	listFn     func(filter string) ([]string, error)
 	loadFn     func(ctx context.Context, name string, profiles []string) (*project.Project, error)
 }
@@ -45,6 +48,7 @@ func New() *Manager {
 	m := &Manager{
 		gitFactory: func(path string) git.Service { return git.New(path) },
 		fs:         fs.New(),
+		indexPath:  filepath.Join(app.AppDir, app.IndexFile),
 	}
 
 	m.listFn = m.list
@@ -55,21 +59,26 @@ func New() *Manager {
 
 // AutodetectProject validates project name (if provided) and tries to autodetect the project by comparing
 // the current directory with the project sources and local mounts. If not successful or ambiguous, it returns an error.
+// Autodetection works on the project index, only the detected project and the projects that changed since they were
+// indexed are loaded.
 func (m *Manager) AutodetectProject(ctx context.Context, name string) (*project.Project, error) {
-	// Load projects
-	projects, err := m.loadAllProjects(ctx)
-	if err != nil {
-		return nil, err
-	}
-
 	// If project name was provided we check it and immediately return if found
 	if name != "" {
-		for _, p := range projects {
-			if p.Name == name {
-				return p, nil
-			}
+		projectNames, err := m.listFn("")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list projects: %w", err)
 		}
-		return nil, fmt.Errorf("project '%s' not found", name)
+
+		if !slices.Contains(projectNames, name) {
+			return nil, fmt.Errorf("project '%s' not found", name)
+		}
+
+		return m.loadProject(ctx, name, nil)
+	}
+
+	entries, loaded, err := m.readIndex(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	// For autodetection, we need the current directory
@@ -79,71 +88,98 @@ func (m *Manager) AutodetectProject(ctx context.Context, name string) (*project.
 	}
 
 	// 1. Check if the current dir is a local mount of one project
-	if p, ambiguous := m.detectByLocalMount(curDir, projects); p != nil {
+	if e, ambiguous := m.detectByLocalMount(curDir, entries); e != nil {
 		if ambiguous {
 			return nil, errors.New("ambiguous project, please specify project name")
 		}
-		return p, nil
+		return m.loadProject(ctx, e.Name, loaded)
 	}
 
-	// 2. Check if current dir matches a project source by git remote
+	// 2. Check if the current dir is inside the directory a project is linked to
+	if e, ambiguous := m.detectByLinkTarget(curDir, entries); e != nil {
+		if ambiguous {
+			return nil, errors.New("ambiguous project, please specify project name")
+		}
+		return m.loadProject(ctx, e.Name, loaded)
+	}
+
+	// 3. Check if current dir matches a project source by git remote
 	curDirGit := m.gitFactory(curDir)
 	remoteURL, err := curDirGit.GetRemote(ctx)
-	if err != nil {
-		return nil, errors.New("cannot detect project: not a git repository")
-	}
+	hasRemote := err == nil
 	remoteURL = git.NormalizeURL(remoteURL)
 
-	if p, ambiguous := m.detectBySourceRemote(remoteURL, projects); p != nil {
-		if ambiguous {
-			return nil, errors.New("ambiguous project, please specify project name")
+	if hasRemote {
+		if e, ambiguous := m.detectBySourceRemote(remoteURL, entries); e != nil {
+			if ambiguous {
+				return nil, errors.New("ambiguous project, please specify project name")
+			}
+			return m.loadProject(ctx, e.Name, loaded)
 		}
-		return p, nil
 	}
 
-	// 3. Check if the current dir is the project's own manifest repository
-	if p, ambiguous := m.detectByProjectRepo(ctx, remoteURL, projects); p != nil {
-		if ambiguous {
-			return nil, errors.New("ambiguous project, please specify project name")
-		}
-		return p, nil
+	// 4. Check if the current dir is the project's own manifest repository
+	var matches []*indexEntry
+	if hasRemote {
+		matches = m.manifestMatches(ctx, remoteURL, entries)
+	} else {
+		m.lookupManifestURLs(ctx, entries)
+	}
+	m.saveIndex(entries) // keeps the manifest remotes looked up above
+
+	// 5. Check if the current dir is the local repository a project was cloned from, it may have no remote
+	if len(matches) == 0 {
+		matches = m.detectByLocalRepo(ctx, curDirGit, entries)
 	}
 
-	return nil, errors.New("cannot detect project: git remote does not match any known project")
+	if len(matches) == 0 && !hasRemote {
+		return nil, errors.New("cannot detect project: not a git repository")
+	}
+
+	// Several projects may share one repo with their manifests in subdirectories
+	if len(matches) > 1 {
+		matches = m.narrowByManifestPath(ctx, curDirGit, curDir, matches)
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, errors.New("cannot detect project: git remote does not match any known project")
+	case 1:
+		return m.loadProject(ctx, matches[0].Name, loaded)
+	default:
+		return nil, errors.New("ambiguous project, please specify project name")
+	}
 }
 
-// loadAllProjects loads all available projects.
-func (m *Manager) loadAllProjects(ctx context.Context) ([]*project.Project, error) {
-	projectNames, err := m.listFn("")
-	if err != nil {
-		return nil, fmt.Errorf("failed to list projects: %w", err)
+// loadProject returns the project if it was loaded already while indexing, otherwise loads it.
+func (m *Manager) loadProject(
+	ctx context.Context, name string, loaded map[string]*project.Project,
+) (*project.Project, error) {
+	if p, ok := loaded[name]; ok {
+		return p, nil
 	}
-	projects := make([]*project.Project, 0, len(projectNames))
 
-	for _, projectName := range projectNames {
-		p, err := m.loadFn(ctx, projectName, []string{"*"})
-		if err != nil {
-			return nil, fmt.Errorf("failed to load project: %w", err)
-		}
-		projects = append(projects, p)
+	p, err := m.loadFn(ctx, name, []string{"*"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project: %w", err)
 	}
 
-	return projects, nil
+	return p, nil
 }
 
 // detectByLocalMount checks if curDir matches any project's local mount.
 // Returns the project and whether the match is ambiguous.
-func (m *Manager) detectByLocalMount(curDir string, projects []*project.Project) (*project.Project, bool) {
-	var found *project.Project
+func (m *Manager) detectByLocalMount(curDir string, entries []*indexEntry) (*indexEntry, bool) {
+	var found *indexEntry
 	ambiguous := false
 
-	for _, p := range projects {
-		for _, mountPath := range p.LocalMounts {
+	for _, e := range entries {
+		for _, mountPath := range e.LocalMounts {
 			if mountPath == curDir {
-				if found != nil && found != p {
+				if found != nil && found != e {
 					ambiguous = true
 				}
-				found = p
+				found = e
 			}
 		}
 	}
@@ -152,56 +188,166 @@ func (m *Manager) detectByLocalMount(curDir string, projects []*project.Project)
 }
 
 // detectBySourceRemote checks if the current git remote matches any project source URL.
-func (m *Manager) detectBySourceRemote(remoteURL string, projects []*project.Project) (*project.Project, bool) {
-	var found *project.Project
+func (m *Manager) detectBySourceRemote(remoteURL string, entries []*indexEntry) (*indexEntry, bool) {
+	var found *indexEntry
 	ambiguous := false
 
-	for _, p := range projects {
-		for _, source := range p.Sources {
-			sourceURL := git.NormalizeURL(source.URL)
+	for _, e := range entries {
+		for _, sourceURL := range e.SourceURLs {
 			if remoteURL != sourceURL {
 				continue
 			}
 
-			if found != nil && found != p {
+			if found != nil && found != e {
 				ambiguous = true
 			}
-			found = p
+			found = e
 		}
 	}
 
 	return found, ambiguous
 }
 
-// detectByProjectRepo checks if the current git remote matches the project's manifest repository.
-func (m *Manager) detectByProjectRepo(
-	ctx context.Context,
-	remoteURL string,
-	projects []*project.Project,
-) (*project.Project, bool) {
-	var found *project.Project
-	ambiguous := false
+// manifestMatches returns the projects whose manifest repo has the remote.
+func (m *Manager) manifestMatches(ctx context.Context, remoteURL string, entries []*indexEntry) []*indexEntry {
+	m.lookupManifestURLs(ctx, entries)
+
+	var matches []*indexEntry
+	for _, e := range entries {
+		if e.ManifestURL != "" && remoteURL == e.ManifestURL {
+			matches = append(matches, e)
+		}
+	}
+
+	return matches
+}
+
+// lookupManifestURLs fills the manifest remotes the index doesn't know yet.
+func (m *Manager) lookupManifestURLs(ctx context.Context, entries []*indexEntry) {
+	for _, e := range entries {
+		if e.ManifestURL != "" {
+			continue
+		}
 
-	for _, p := range projects {
-		projectRemoteURL, err := m.gitFactory(p.WorkingDir).GetRemote(ctx)
+		projectRemoteURL, err := m.gitFactory(e.WorkingDir).GetRemote(ctx)
 		if err != nil {
 			continue
 		}
-		projectRemoteURL = git.NormalizeURL(projectRemoteURL)
+		e.ManifestURL = git.NormalizeURL(projectRemoteURL)
+	}
+}
 
-		if remoteURL != projectRemoteURL {
+// detectByLinkTarget checks if the current dir is inside the directory of a linked project.
+func (m *Manager) detectByLinkTarget(curDir string, entries []*indexEntry) (*indexEntry, bool) {
+	if resolved, err := filepath.EvalSymlinks(curDir); err == nil {
+		curDir = resolved
+	}
+
+	var found *indexEntry
+	ambiguous := false
+
+	for _, e := range entries {
+		target, linked := project.LinkTarget(e.WorkingDir)
+		if !linked || (curDir != target && !strings.HasPrefix(curDir, target+string(filepath.Separator))) {
 			continue
 		}
 
-		if found != nil && found != p {
+		if found != nil && found != e {
 			ambiguous = true
 		}
-		found = p
+		found = e
 	}
 
 	return found, ambiguous
 }
 
+// detectByLocalRepo returns the projects cloned from the repo of the current dir through a file:// remote.
+func (m *Manager) detectByLocalRepo(ctx context.Context, curDirGit git.Service, entries []*indexEntry) []*indexEntry {
+	if !slices.ContainsFunc(entries, func(e *indexEntry) bool { return strings.HasPrefix(e.ManifestURL, "/") }) {
+		return nil
+	}
+
+	topLevel, err := curDirGit.GetTopLevel(ctx)
+	if err != nil {
+		return nil
+	}
+	topLevel = git.NormalizeURL(topLevel)
+
+	var matches []*indexEntry
+	for _, e := range entries {
+		if e.ManifestURL == topLevel {
+			matches = append(matches, e)
+		}
+	}
+
+	return matches
+}
+
+// narrowByManifestPath keeps the projects whose manifest directory in the repo holds the current dir, the
+// deepest one wins. If nothing is left, the matches are returned as they are.
+func (m *Manager) narrowByManifestPath(
+	ctx context.Context,
+	curDirGit git.Service,
+	curDir string,
+	matches []*indexEntry,
+) []*indexEntry {
+	curPath, err := repoRelPath(ctx, curDirGit, curDir)
+	if err != nil {
+		return matches
+	}
+
+	var narrowed []*indexEntry
+	depth := -2 // below the repo root, which is -1
+
+	for _, e := range matches {
+		manifestPath, err := repoRelPath(ctx, m.gitFactory(e.WorkingDir), e.WorkingDir)
+		if err != nil {
+			continue
+		}
+
+		if manifestPath != "." && curPath != manifestPath && !strings.HasPrefix(curPath, manifestPath+"/") {
+			continue
+		}
+
+		d := strings.Count(manifestPath, "/")
+		if manifestPath == "." {
+			d = -1
+		}
+
+		switch {
+		case d > depth:
+			narrowed, depth = []*indexEntry{e}, d
+		case d == depth:
+			narrowed = append(narrowed, e)
+		}
+	}
+
+	if len(narrowed) == 0 {
+		return matches
+	}
+
+	return narrowed
+}
+
+// repoRelPath returns dir relative to the top level of its repo, with symlinks resolved the way git does.
+func repoRelPath(ctx context.Context, g git.Service, dir string) (string, error) {
+	topLevel, err := g.GetTopLevel(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get top level: %w", err)
+	}
+
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+
+	rel, err := filepath.Rel(topLevel, dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get relative path: %w", err)
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
 // AutodetectSource detects sources and affected services based on current directory or explicit selection.
 func (m *Manager) AutodetectSource(
 	ctx context.Context,
@@ -512,11 +658,15 @@ func (m *Manager) list(filter string) ([]string, error) {
 
 	results := make([]string, 0, len(folders))
 	for _, folder := range folders {
-		if !folder.IsDir() {
+		if !folder.IsDir() && !m.isDirLink(folder) {
 			continue
 		}
 
 		name := folder.Name()
+		if strings.HasPrefix(name, ".") {
+			continue // shared data such as the git cache, never a project
+		}
+
 		if filter != "" && !strings.HasPrefix(strings.ToLower(name), strings.ToLower(filter)) {
 			continue
 		}
@@ -527,6 +677,18 @@ func (m *Manager) list(filter string) ([]string, error) {
 	return results, nil
 }
 
+// isDirLink reports whether the entry is a symlink to a directory, as linked projects and the ones in a
+// subdirectory of their repo are.
+func (m *Manager) isDirLink(entry fs.DirEntry) bool {
+	if entry.Type()&os.ModeSymlink == 0 {
+		return false
+	}
+
+	info, err := m.fs.Stat(filepath.Join(app.AppDir, entry.Name()))
+
+	return err == nil && info.IsDir()
+}
+
 func (m *Manager) load(ctx context.Context, name string, profiles []string) (*project.Project, error) {
 	p, err := project.New(ctx, name, profiles)
 	if err != nil {
@@ -535,44 +697,133 @@ func (m *Manager) load(ctx context.Context, name string, profiles []string) (*pr
 	return p, nil
 }
 
-func (m *Manager) Init(ctx context.Context, name, url, branch string) error {
-	if !validNameRegex.MatchString(name) {
-		return fmt.Errorf("invalid project name: %s", name)
+// Init clones the project manifest. When the manifest is in a subdirectory of the repo, the repo is cloned into
+// the repos directory and the project is a symlink to that subdirectory.
+func (m *Manager) Init(ctx context.Context, name, url, branch, path string) error {
+	projectFolder, err := m.newProjectFolder(name)
+	if err != nil {
+		return err
 	}
 
-	projectFolder := filepath.Join(app.AppDir, name)
+	repoFolder := projectFolder
+	if path != "" {
+		if path, err = cleanManifestPath(path); err != nil {
+			return err
+		}
 
-	if _, err := m.fs.Stat(projectFolder); err == nil {
-		return errors.New("project already exists")
+		repoFolder = filepath.Join(app.AppDir, app.ReposDir, name)
+		if _, err := m.fs.Stat(repoFolder); err == nil {
+			return errors.New("project already exists")
+		}
 	}
 
 	cleanup := func() {
 		_ = m.fs.RemoveAll(projectFolder)
+		_ = m.fs.RemoveAll(repoFolder)
 	}
 
-	g := m.gitFactory(projectFolder)
+	g := m.gitFactory(repoFolder)
 	if err := g.Clone(ctx, url, branch); err != nil {
 		cleanup()
 		return fmt.Errorf("failed to clone git repo: %w", err)
 	}
 
-	patterns := []string{
-		"/" + app.SourcesDir + "/",
-		"/" + app.StateFile,
-		"/" + app.EnvFile,
+	manifestFolder := repoFolder
+	if path != "" {
+		manifestFolder = filepath.Join(repoFolder, path)
+		if info, err := m.fs.Stat(manifestFolder); err != nil || !info.IsDir() {
+			cleanup()
+			return fmt.Errorf("directory %s not found in the repo", path)
+		}
 	}
 
-	if err := g.SetLocalExclude(patterns); err != nil {
+	if err := g.SetLocalExclude(projectExcludes(path)); err != nil {
 		cleanup()
 		return fmt.Errorf("failed to set local exclude: %w", err)
 	}
 
+	if err := m.writeProjectFiles(manifestFolder, true); err != nil {
+		cleanup()
+		return err
+	}
+
+	if path != "" {
+		if err := m.fs.Symlink(manifestFolder, projectFolder); err != nil {
+			cleanup()
+			return fmt.Errorf("failed to link project: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Link makes a project of a local directory as it is, the manifest there is managed by the user and never
+// updated by devbox.
+func (m *Manager) Link(ctx context.Context, name, dir string) error {
+	projectFolder, err := m.newProjectFolder(name)
+	if err != nil {
+		return err
+	}
+
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	if info, err := m.fs.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("directory %s not found", dir)
+	}
+
+	// The user's repo shouldn't show what devbox writes there as changes. Not being a repo is fine
+	g := m.gitFactory(dir)
+	if topLevel, err := g.GetTopLevel(ctx); err == nil {
+		if rel, err := filepath.Rel(topLevel, dir); err == nil {
+			if err := g.AddLocalExclude(ctx, projectExcludes(filepath.ToSlash(rel))); err != nil {
+				return fmt.Errorf("failed to set local exclude: %w", err)
+			}
+		}
+	}
+
+	if err := m.writeProjectFiles(dir, false); err != nil {
+		return err
+	}
+
+	if err := m.fs.Symlink(dir, projectFolder); err != nil {
+		return fmt.Errorf("failed to link project: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Manager) newProjectFolder(name string) (string, error) {
+	if !validNameRegex.MatchString(name) {
+		return "", fmt.Errorf("invalid project name: %s", name)
+	}
+
+	projectFolder := filepath.Join(app.AppDir, name)
+
+	if _, err := m.fs.Stat(projectFolder); err == nil {
+		return "", errors.New("project already exists")
+	}
+
+	return projectFolder, nil
+}
+
+// writeProjectFiles creates the env and state files of a new project. The existing ones are kept unless
+// overwrite is set, a linked directory may have been a project before.
+func (m *Manager) writeProjectFiles(dir string, overwrite bool) error {
 	for k, content := range map[string]string{
 		app.EnvFile:   "",
 		app.StateFile: "{}",
 	} {
-		if err := m.fs.WriteFile(filepath.Join(projectFolder, k), []byte(content), 0o644); err != nil {
-			cleanup()
+		filename := filepath.Join(dir, k)
+		if !overwrite {
+			if _, err := m.fs.Stat(filename); err == nil {
+				continue
+			}
+		}
+
+		if err := m.fs.WriteFile(filename, []byte(content), 0o644); err != nil {
 			return fmt.Errorf("failed to create file: %w", err)
 		}
 	}
@@ -580,15 +831,49 @@ func (m *Manager) Init(ctx context.Context, name, url, branch string) error {
 	return nil
 }
 
+// projectExcludes returns the exclude patterns of the files devbox writes next to the manifest, path is the
+// manifest directory relative to the repo.
+func projectExcludes(path string) []string {
+	prefix := "/"
+	if path != "" && path != "." {
+		prefix += strings.Trim(path, "/") + "/"
+	}
+
+	return []string{
+		prefix + app.SourcesDir + "/",
+		prefix + app.StateFile,
+		prefix + app.EnvFile,
+	}
+}
+
+// cleanManifestPath checks that the manifest path stays inside the repo.
+func cleanManifestPath(path string) (string, error) {
+	path = filepath.Clean(filepath.FromSlash(path))
+	if !filepath.IsLocal(path) {
+		return "", fmt.Errorf("invalid manifest path: %s", path)
+	}
+
+	if path == "." {
+		return "", nil
+	}
+
+	return path, nil
+}
+
 func (m *Manager) Destroy(ctx context.Context, proj *project.Project) error {
 	if _, err := m.fs.Stat(proj.WorkingDir); err != nil {
 		return fmt.Errorf("project %s does not exist", proj.Name)
 	}
 
+	// A linked project or one in a subdirectory is a symlink, removing it keeps what it points to
 	if err := m.fs.RemoveAll(proj.WorkingDir); err != nil {
 		return fmt.Errorf("failed to remove project: %w", err)
 	}
 
+	if err := m.fs.RemoveAll(filepath.Join(app.AppDir, app.ReposDir, proj.Name)); err != nil {
+		return fmt.Errorf("failed to remove project repo: %w", err)
+	}
+
 	return nil
 }
 
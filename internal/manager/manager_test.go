@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/compose-spec/compose-go/v2/types"
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/pilat/devbox/internal/app"
 	"github.com/pilat/devbox/internal/git"
 	"github.com/pilat/devbox/internal/pkg/fs"
 	"github.com/pilat/devbox/internal/project"
@@ -30,6 +32,15 @@ func mockGitFactory(t *testing.T, pathMocks map[string]*git.MockService) gitServ
 	}
 }
 
+// indexProjects returns the index entries of the projects.
+func indexProjects(projects []*project.Project) []*indexEntry {
+	entries := make([]*indexEntry, 0, len(projects))
+	for _, p := range projects {
+		entries = append(entries, newIndexEntry(p))
+	}
+	return entries
+}
+
 // newDirFileInfo creates a MockFileInfo that returns true for IsDir().
 func newDirFileInfo(t *testing.T) *fs.MockFileInfo {
 	fi := fs.NewMockFileInfo(t)
@@ -124,7 +135,7 @@ func TestDetectByLocalMount(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			m := New()
-			got, ambig := m.detectByLocalMount(tt.curDir, tt.projects)
+			got, ambig := m.detectByLocalMount(tt.curDir, indexProjects(tt.projects))
 
 			if ambig != tt.wantAmbig {
 				t.Errorf("detectByLocalMount() ambiguous = %v, want %v", ambig, tt.wantAmbig)
@@ -228,7 +239,7 @@ func TestDetectBySourceRemote(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			m := New()
-			got, ambig := m.detectBySourceRemote(tt.remoteURL, tt.projects)
+			got, ambig := m.detectBySourceRemote(tt.remoteURL, indexProjects(tt.projects))
 
 			if ambig != tt.wantAmbig {
 				t.Errorf("detectBySourceRemote() ambiguous = %v, want %v", ambig, tt.wantAmbig)
@@ -246,17 +257,16 @@ func TestDetectBySourceRemote(t *testing.T) {
 }
 
 // ============================================================================
-// detectByProjectRepo tests
+// manifestMatches tests
 // ============================================================================
 
-func TestDetectByProjectRepo(t *testing.T) {
+func TestManifestMatches(t *testing.T) {
 	tests := []struct {
-		name        string
-		remoteURL   string
-		projects    []*project.Project
-		setupGit    func(t *testing.T) map[string]*git.MockService
-		wantProject string
-		wantAmbig   bool
+		name         string
+		remoteURL    string
+		projects     []*project.Project
+		setupGit     func(t *testing.T) map[string]*git.MockService
+		wantProjects []string
 	}{
 		{
 			name:      "match project repo",
@@ -276,8 +286,7 @@ func TestDetectByProjectRepo(t *testing.T) {
 					"/home/user/.devbox/myproject": m,
 				}
 			},
-			wantProject: testProjectName,
-			wantAmbig:   false,
+			wantProjects: []string{testProjectName},
 		},
 		{
 			name:      "no match",
@@ -297,8 +306,7 @@ func TestDetectByProjectRepo(t *testing.T) {
 					"/home/user/.devbox/myproject": m,
 				}
 			},
-			wantProject: "",
-			wantAmbig:   false,
+			wantProjects: nil,
 		},
 		{
 			name:      "git error - skip project",
@@ -318,8 +326,26 @@ func TestDetectByProjectRepo(t *testing.T) {
 					"/home/user/.devbox/myproject": m,
 				}
 			},
-			wantProject: "",
-			wantAmbig:   false,
+			wantProjects: nil,
+		},
+		{
+			name:      "projects sharing the repo",
+			remoteURL: "github.com/company/devbox-config",
+			projects: []*project.Project{
+				{Project: &types.Project{Name: "api", WorkingDir: "/home/user/.devbox/api"}},
+				{Project: &types.Project{Name: "web", WorkingDir: "/home/user/.devbox/web"}},
+			},
+			setupGit: func(t *testing.T) map[string]*git.MockService {
+				api := git.NewMockService(t)
+				api.EXPECT().GetRemote(mock.Anything).Return("https://github.com/company/devbox-config.git", nil)
+				web := git.NewMockService(t)
+				web.EXPECT().GetRemote(mock.Anything).Return("git@github.com:company/devbox-config.git", nil)
+				return map[string]*git.MockService{
+					"/home/user/.devbox/api": api,
+					"/home/user/.devbox/web": web,
+				}
+			},
+			wantProjects: []string{"api", "web"},
 		},
 	}
 
@@ -330,19 +356,13 @@ func TestDetectByProjectRepo(t *testing.T) {
 
 			m := New()
 			m.gitFactory = gitFactory
-			got, ambig := m.detectByProjectRepo(context.Background(), tt.remoteURL, tt.projects)
+			got := m.manifestMatches(context.Background(), tt.remoteURL, indexProjects(tt.projects))
 
-			if ambig != tt.wantAmbig {
-				t.Errorf("detectByProjectRepo() ambiguous = %v, want %v", ambig, tt.wantAmbig)
-			}
-
-			gotName := ""
-			if got != nil {
-				gotName = got.Name
-			}
-			if gotName != tt.wantProject {
-				t.Errorf("detectByProjectRepo() project = %q, want %q", gotName, tt.wantProject)
+			var gotNames []string
+			for _, e := range got {
+				gotNames = append(gotNames, e.Name)
 			}
+			assert.Equal(t, tt.wantProjects, gotNames)
 		})
 	}
 }
@@ -532,6 +552,205 @@ func TestAutodetectProject_Ambiguous(t *testing.T) {
 	assert.Equal(t, "ambiguous project, please specify project name", err.Error())
 }
 
+func TestAutodetectProject_ByManifestPath(t *testing.T) {
+	appDir := t.TempDir()
+	oldAppDir := app.AppDir
+	app.AppDir = appDir
+	t.Cleanup(func() { app.AppDir = oldAppDir })
+
+	// Two projects of one repo, the current dir is inside the manifest directory of the second one
+	repo := t.TempDir()
+	for _, dir := range []string{"environments/blog", "environments/shop/nginx"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(repo, dir), 0o755))
+	}
+	curDir := filepath.Join(repo, "environments", "shop", "nginx")
+
+	curDirMock := git.NewMockService(t)
+	curDirMock.EXPECT().GetRemote(mock.Anything).Return("git@github.com:company/infra.git", nil)
+	curDirMock.EXPECT().GetTopLevel(mock.Anything).Return(repo, nil)
+
+	pathMocks := map[string]*git.MockService{curDir: curDirMock}
+	for _, name := range []string{"blog", "shop"} {
+		projectMock := git.NewMockService(t)
+		projectMock.EXPECT().GetRemote(mock.Anything).Return("https://github.com/company/infra.git", nil)
+		projectMock.EXPECT().GetTopLevel(mock.Anything).Return(repo, nil)
+		pathMocks[filepath.Join(repo, "environments", name)] = projectMock
+	}
+
+	mockFS := fs.NewMockFileSystem(t)
+	mockFS.EXPECT().Getwd().Return(curDir, nil)
+
+	m := New()
+	m.indexPath = filepath.Join(appDir, app.IndexFile)
+	m.listFn = func(filter string) ([]string, error) {
+		return []string{"blog", "shop"}, nil
+	}
+	m.loadFn = func(ctx context.Context, name string, profiles []string) (*project.Project, error) {
+		workingDir := filepath.Join(repo, "environments", name)
+		return &project.Project{Project: &types.Project{Name: name, WorkingDir: workingDir}}, nil
+	}
+	m.gitFactory = mockGitFactory(t, pathMocks)
+	m.fs = mockFS
+
+	got, err := m.AutodetectProject(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "shop", got.Name)
+}
+
+func TestAutodetectProject_ByLinkTarget(t *testing.T) {
+	appDir := t.TempDir()
+	oldAppDir := app.AppDir
+	app.AppDir = appDir
+	t.Cleanup(func() { app.AppDir = oldAppDir })
+
+	// No git is needed, the current dir is inside the directory the project is linked to
+	localDir := t.TempDir()
+	curDir := filepath.Join(localDir, "nginx")
+	require.NoError(t, os.MkdirAll(curDir, 0o755))
+	require.NoError(t, os.Symlink(localDir, filepath.Join(appDir, testProjectName)))
+
+	mockFS := fs.NewMockFileSystem(t)
+	mockFS.EXPECT().Getwd().Return(curDir, nil)
+
+	m := New()
+	m.indexPath = filepath.Join(appDir, app.IndexFile)
+	m.listFn = func(filter string) ([]string, error) {
+		return []string{testProjectName}, nil
+	}
+	m.loadFn = func(ctx context.Context, name string, profiles []string) (*project.Project, error) {
+		workingDir := filepath.Join(appDir, name)
+		return &project.Project{Project: &types.Project{Name: name, WorkingDir: workingDir}}, nil
+	}
+	m.gitFactory = mockGitFactory(t, nil)
+	m.fs = mockFS
+
+	got, err := m.AutodetectProject(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, testProjectName, got.Name)
+}
+
+func TestAutodetectProject_ByLocalRepo(t *testing.T) {
+	curDirMock := git.NewMockService(t)
+	curDirMock.EXPECT().GetRemote(mock.Anything).Return("https://github.com/company/devbox-config.git", nil)
+	curDirMock.EXPECT().GetTopLevel(mock.Anything).Return("/home/user/devbox-config", nil)
+
+	// The project was cloned from the repo on disk, not from its remote
+	projectMock := git.NewMockService(t)
+	projectMock.EXPECT().GetRemote(mock.Anything).Return("file:///home/user/devbox-config", nil)
+
+	pathMocks := map[string]*git.MockService{
+		"/home/user/devbox-config":     curDirMock,
+		"/home/user/.devbox/myproject": projectMock,
+	}
+
+	mockFS := fs.NewMockFileSystem(t)
+	mockFS.EXPECT().Getwd().Return("/home/user/devbox-config", nil)
+
+	m := New()
+	m.listFn = func(filter string) ([]string, error) {
+		return []string{testProjectName}, nil
+	}
+	m.loadFn = func(ctx context.Context, name string, profiles []string) (*project.Project, error) {
+		return &project.Project{
+			Project: &types.Project{
+				Name:       testProjectName,
+				WorkingDir: "/home/user/.devbox/myproject",
+			},
+		}, nil
+	}
+	m.gitFactory = mockGitFactory(t, pathMocks)
+	m.fs = mockFS
+
+	got, err := m.AutodetectProject(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, testProjectName, got.Name)
+}
+
+// ============================================================================
+// Project index tests
+// ============================================================================
+
+func TestAutodetectProject_UsesIndex(t *testing.T) {
+	appDir := t.TempDir()
+	oldAppDir := app.AppDir
+	app.AppDir = appDir
+	t.Cleanup(func() { app.AppDir = oldAppDir })
+
+	for _, name := range []string{"proj1", "proj2"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(appDir, name), 0o755))
+		composeFile := filepath.Join(appDir, name, "docker-compose.yml")
+		require.NoError(t, os.WriteFile(composeFile, []byte("services: {}"), 0o644))
+	}
+
+	mockFS := fs.NewMockFileSystem(t)
+	mockFS.EXPECT().Getwd().Return("/home/user/backend", nil)
+
+	loads := map[string]int{}
+
+	m := New()
+	m.indexPath = filepath.Join(appDir, app.IndexFile)
+	m.fs = mockFS
+	m.listFn = func(filter string) ([]string, error) {
+		return []string{"proj1", "proj2"}, nil
+	}
+	m.loadFn = func(ctx context.Context, name string, profiles []string) (*project.Project, error) {
+		loads[name]++
+		p := &project.Project{Project: &types.Project{Name: name, WorkingDir: filepath.Join(appDir, name)}}
+		if name == "proj1" {
+			p.LocalMounts = map[string]string{"./sources/backend": "/home/user/backend"}
+		}
+		return p, nil
+	}
+
+	// Indexing loads every project once, the detected one is not loaded again
+	got, err := m.AutodetectProject(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "proj1", got.Name)
+	assert.Equal(t, map[string]int{"proj1": 1, "proj2": 1}, loads)
+
+	// With a fresh index only the detected project is loaded
+	got, err = m.AutodetectProject(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "proj1", got.Name)
+	assert.Equal(t, map[string]int{"proj1": 2, "proj2": 1}, loads)
+
+	// A changed project is indexed again
+	require.NoError(t, os.WriteFile(filepath.Join(appDir, "proj2", ".devboxstate"), []byte("{}"), 0o644))
+
+	_, err = m.AutodetectProject(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"proj1": 3, "proj2": 2}, loads)
+}
+
+func TestFingerprintProject(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte("services: {}"), 0o644))
+
+	first, err := fingerprintProject(dir)
+	require.NoError(t, err)
+
+	again, err := fingerprintProject(dir)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	// Files in the sources directory don't matter, files at the top do
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sources", "api"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sources", "api", "main.go"), []byte("package main"), 0o644))
+
+	again, err = fingerprintProject(dir)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte("services: {a: {}}"), 0o644))
+
+	changed, err := fingerprintProject(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, changed)
+
+	_, err = fingerprintProject(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
+
 // ============================================================================
 // AutodetectSource tests
 // ============================================================================
@@ -1194,6 +1413,7 @@ func TestInit(t *testing.T) {
 		projName string
 		url      string
 		branch   string
+		path     string
 		setupFS  func(t *testing.T) *fs.MockFileSystem
 		setupGit func(t *testing.T) map[string]*git.MockService
 		wantErr  string
@@ -1220,6 +1440,37 @@ func TestInit(t *testing.T) {
 			setupGit: func(t *testing.T) map[string]*git.MockService { return nil },
 			wantErr:  "project already exists",
 		},
+		{
+			name:     "manifest path outside the repo",
+			projName: "myproject",
+			url:      "https://github.com/company/infra.git",
+			path:     "../other",
+			setupFS: func(t *testing.T) *fs.MockFileSystem {
+				mockFS := fs.NewMockFileSystem(t)
+				mockFS.EXPECT().Stat(mock.Anything).Return(nil, os.ErrNotExist)
+				return mockFS
+			},
+			setupGit: func(t *testing.T) map[string]*git.MockService { return nil },
+			wantErr:  "invalid manifest path",
+		},
+		{
+			name:     "manifest path not in the repo",
+			projName: "myproject",
+			url:      "https://github.com/company/infra.git",
+			path:     "environments/shop",
+			setupFS: func(t *testing.T) *fs.MockFileSystem {
+				mockFS := fs.NewMockFileSystem(t)
+				mockFS.EXPECT().Stat(mock.Anything).Return(nil, os.ErrNotExist)
+				mockFS.EXPECT().RemoveAll(mock.Anything).Return(nil)
+				return mockFS
+			},
+			setupGit: func(t *testing.T) map[string]*git.MockService {
+				m := git.NewMockService(t)
+				m.EXPECT().Clone(mock.Anything, "https://github.com/company/infra.git", "").Return(nil)
+				return map[string]*git.MockService{mock.Anything: m}
+			},
+			wantErr: "directory environments/shop not found in the repo",
+		},
 		{
 			name:     "clone error",
 			projName: "myproject",
@@ -1260,7 +1511,7 @@ func TestInit(t *testing.T) {
 			m.gitFactory = gitFactory
 			m.fs = mockFS
 
-			err := m.Init(context.Background(), tt.projName, tt.url, tt.branch)
+			err := m.Init(context.Background(), tt.projName, tt.url, tt.branch, tt.path)
 
 			if tt.wantErr != "" {
 				if err == nil {
@@ -1279,10 +1530,77 @@ func TestInit(t *testing.T) {
 	}
 }
 
+func TestProjectExcludes(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{
+			name: "repo root",
+			path: "",
+			want: []string{"/sources/", "/.devboxstate", "/.env"},
+		},
+		{
+			name: "current dir",
+			path: ".",
+			want: []string{"/sources/", "/.devboxstate", "/.env"},
+		},
+		{
+			name: "subdirectory",
+			path: "environments/shop",
+			want: []string{"/environments/shop/sources/", "/environments/shop/.devboxstate", "/environments/shop/.env"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, projectExcludes(tt.path))
+		})
+	}
+}
+
 // ============================================================================
 // Destroy tests
 // ============================================================================
 
+func TestList(t *testing.T) {
+	dirEntry := func(name string, isDir bool) fs.DirEntry {
+		entry := fs.NewMockDirEntry(t)
+		entry.EXPECT().IsDir().Return(isDir)
+		entry.EXPECT().Name().Return(name).Maybe()
+		entry.EXPECT().Type().Return(0).Maybe()
+		return entry
+	}
+
+	linkEntry := func(name string) fs.DirEntry {
+		entry := fs.NewMockDirEntry(t)
+		entry.EXPECT().IsDir().Return(false)
+		entry.EXPECT().Name().Return(name)
+		entry.EXPECT().Type().Return(os.ModeSymlink)
+		return entry
+	}
+
+	mockFS := fs.NewMockFileSystem(t)
+	mockFS.EXPECT().ReadDir(mock.Anything).Return([]fs.DirEntry{
+		dirEntry("myproject", true),
+		dirEntry(".cache", true),
+		dirEntry("other", true),
+		dirEntry("notes.txt", false),
+		linkEntry("linked"),
+		linkEntry("dangling"),
+	}, nil)
+	mockFS.EXPECT().Stat(filepath.Join(app.AppDir, "linked")).Return(newDirFileInfo(t), nil)
+	mockFS.EXPECT().Stat(filepath.Join(app.AppDir, "dangling")).Return(nil, os.ErrNotExist)
+
+	m := New()
+	m.fs = mockFS
+
+	got, err := m.List("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"myproject", "other", "linked"}, got)
+}
+
 func TestDestroy(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -13,6 +13,7 @@ type FileSystem interface {
 	ReadDir(path string) ([]DirEntry, error)
 	WriteFile(path string, data []byte, perm os.FileMode) error
 	RemoveAll(path string) error
+	Symlink(oldname, newname string) error
 }
 
 // FileInfo mirrors os.FileInfo to enable mocking (mockery has issues with stdlib interfaces).
@@ -98,3 +99,10 @@ func (f *OSFileSystem) RemoveAll(path string) error {
 	}
 	return nil
 }
+
+func (f *OSFileSystem) Symlink(oldname, newname string) error {
+	if err := os.Symlink(oldname, newname); err != nil {
+		return fmt.Errorf("failed to link %s to %s: %w", newname, oldname, err)
+	}
+	return nil
+}
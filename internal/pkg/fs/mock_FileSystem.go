@@ -238,6 +238,53 @@ func (_c *MockFileSystem_Stat_Call) RunAndReturn(run func(string) (FileInfo, err
 	return _c
 }
 
+// Symlink provides a mock function with given fields: oldname, newname
+func (_m *MockFileSystem) Symlink(oldname string, newname string) error {
+	ret := _m.Called(oldname, newname)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Symlink")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(oldname, newname)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockFileSystem_Symlink_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Symlink'
+type MockFileSystem_Symlink_Call struct {
+	*mock.Call
+}
+
+// Symlink is a helper method to define mock.On call
+//   - oldname string
+//   - newname string
+func (_e *MockFileSystem_Expecter) Symlink(oldname interface{}, newname interface{}) *MockFileSystem_Symlink_Call {
+	return &MockFileSystem_Symlink_Call{Call: _e.mock.On("Symlink", oldname, newname)}
+}
+
+func (_c *MockFileSystem_Symlink_Call) Run(run func(oldname string, newname string)) *MockFileSystem_Symlink_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockFileSystem_Symlink_Call) Return(_a0 error) *MockFileSystem_Symlink_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockFileSystem_Symlink_Call) RunAndReturn(run func(string, string) error) *MockFileSystem_Symlink_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // WriteFile provides a mock function with given fields: path, data, perm
 func (_m *MockFileSystem) WriteFile(path string, data []byte, perm os.FileMode) error {
 	ret := _m.Called(path, data, perm)
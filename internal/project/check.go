@@ -0,0 +1,79 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pilat/devbox/internal/app"
+)
+
+// DanglingMounts returns the local mounts whose local path is gone, by mount path.
+func (p *Project) DanglingMounts() map[string]string {
+	dangling := map[string]string{}
+	for mountPath, localPath := range p.LocalMounts {
+		if _, err := os.Stat(localPath); os.IsNotExist(err) {
+			dangling[mountPath] = localPath
+		}
+	}
+
+	return dangling
+}
+
+// UnknownMounts returns the local mounts of the sources the manifest doesn't have anymore, by mount path.
+func (p *Project) UnknownMounts() map[string]string {
+	unknown := map[string]string{}
+	for mountPath, localPath := range p.LocalMounts {
+		rest, inSources := strings.CutPrefix(mountPath, "./"+app.SourcesDir+"/")
+		name, _, _ := strings.Cut(rest, "/")
+		if _, ok := p.Sources[name]; !inSources || !ok {
+			unknown[mountPath] = localPath
+		}
+	}
+
+	return unknown
+}
+
+// UnknownOverrides returns the sources overridden in the state that the manifest doesn't have anymore.
+func (p *Project) UnknownOverrides() []string {
+	var unknown []string
+	for name := range p.SourceOverrides {
+		if _, ok := p.Sources[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+
+	sort.Strings(unknown)
+
+	return unknown
+}
+
+// OrphanedSources returns the directories in the sources directory of the sources the manifest doesn't have
+// anymore, their worktrees included. Backups belong to the user and are never orphaned.
+func (p *Project) OrphanedSources() ([]string, error) {
+	sourcesDir := filepath.Join(p.WorkingDir, app.SourcesDir)
+
+	var orphaned []string
+	for _, dir := range []string{sourcesDir, filepath.Join(sourcesDir, WorktreesDir)} {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read sources directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+
+			if _, ok := p.Sources[entry.Name()]; !ok {
+				orphaned = append(orphaned, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+
+	return orphaned, nil
+}
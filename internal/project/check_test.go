@@ -0,0 +1,80 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDanglingMounts(t *testing.T) {
+	existing := t.TempDir()
+	missing := filepath.Join(existing, "removed")
+
+	p := &Project{
+		LocalMounts: map[string]string{
+			"./sources/web": existing,
+			"./sources/api": missing,
+		},
+	}
+
+	assert.Equal(t, map[string]string{"./sources/api": missing}, p.DanglingMounts())
+}
+
+func TestUnknownMounts(t *testing.T) {
+	p := &Project{
+		Sources: SourceConfigs{"api": {}, "web": {}},
+		LocalMounts: map[string]string{
+			"./sources/web":         "/home/me/web",
+			"./sources/api/cmd/app": "/home/me/api/cmd/app",
+			"./sources/billing":     "/home/me/billing",
+			"./sources/api-gateway": "/home/me/gateway",
+		},
+	}
+
+	assert.Equal(t, map[string]string{
+		"./sources/billing":     "/home/me/billing",
+		"./sources/api-gateway": "/home/me/gateway",
+	}, p.UnknownMounts())
+}
+
+func TestUnknownOverrides(t *testing.T) {
+	p := &Project{
+		Sources:         SourceConfigs{"api": {}},
+		SourceOverrides: map[string]string{"api": "feature", "web": "main", "billing": "v2"},
+	}
+
+	assert.Equal(t, []string{"billing", "web"}, p.UnknownOverrides())
+}
+
+func TestOrphanedSources(t *testing.T) {
+	workingDir := t.TempDir()
+	sourcesDir := filepath.Join(workingDir, "sources")
+
+	for _, dir := range []string{"api", "billing", ".backups/api", ".worktrees/api/fix", ".worktrees/billing/fix"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(sourcesDir, dir), 0o755))
+	}
+
+	p := &Project{
+		Project: &types.Project{WorkingDir: workingDir},
+		Sources: SourceConfigs{"api": {}, "web": {}},
+	}
+
+	got, err := p.OrphanedSources()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(sourcesDir, "billing"),
+		filepath.Join(sourcesDir, ".worktrees", "billing"),
+	}, got)
+}
+
+func TestOrphanedSources_NoSources(t *testing.T) {
+	p := &Project{Project: &types.Project{WorkingDir: t.TempDir()}}
+
+	got, err := p.OrphanedSources()
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
@@ -3,13 +3,35 @@ package project
 type (
 	SourceConfigs map[string]SourceConfig
 	SourceConfig  struct {
+		Type           string   `yaml:"type"` // git (default), path or archive
 		URL            string   `yaml:"url"`
+		Path           string   `yaml:"path"`     // local directory of a path source
+		Checksum       string   `yaml:"checksum"` // "sha256:<hex>" of an archive source
 		Branch         string   `yaml:"branch"`
+		Tag            string   `yaml:"tag"`
+		Commit         string   `yaml:"commit"` // full commit hash
+		Submodules     bool     `yaml:"submodules"`
+		LFS            bool     `yaml:"lfs"`
+		Depth          int      `yaml:"depth"`       // commits of history to fetch, defaults to 1
+		FullHistory    bool     `yaml:"fullHistory"` // fetch the whole history
+		Filter         string   `yaml:"filter"`      // partial clone filter, e.g. "blob:none"
+		SharedCache    bool     `yaml:"sharedCache"` // borrow objects from a cache shared between projects
 		SparseCheckout []string `yaml:"sparseCheckout"`
 		Environment    []string `yaml:"environment"`
+
+		// MountedEnvironment overrides Environment when the source is replaced by a local mount
+		MountedEnvironment []string `yaml:"mountedEnvironment"`
 	}
 )
 
+// Scenario modes define where the scenario command is executed
+const (
+	ScenarioModeAuto = "auto" // exec in the running container, fallback to a one-off container
+	ScenarioModeExec = "exec" // exec in the running container only
+	ScenarioModeRun  = "run"  // always start a one-off container
+	ScenarioModeHost = "host" // run on the host in the project or source directory
+)
+
 type (
 	ScenarioConfigs map[string]ScenarioConfig
 	ScenarioConfig  struct {
@@ -21,6 +43,68 @@ type (
 		Interactive *bool    `yaml:"stdin_open"` // default: true
 		WorkingDir  string   `yaml:"working_dir"`
 		User        string   `yaml:"user"`
+		Mode        string   `yaml:"mode"`   // default: auto
+		Source      string   `yaml:"source"` // host mode only, default: project directory
+
+		Parameters ScenarioParameters `yaml:"parameters"` // named parameters, referenced as {{name}}
+
+		Steps           []ScenarioStep `yaml:"steps"`             // ordered steps, mutually exclusive with command
+		DependsOn       []string       `yaml:"depends_on"`        // scenarios to run before this one
+		ContinueOnError bool           `yaml:"continue_on_error"` // keep going when a step fails
+	}
+	ScenarioStep struct {
+		Name            string   `yaml:"name"`
+		Service         string   `yaml:"service"`
+		Command         []string `yaml:"command"`
+		Entrypoint      []string `yaml:"entrypoint"`
+		Tty             *bool    `yaml:"tty"`               // default: auto-detect
+		Interactive     *bool    `yaml:"stdin_open"`        // default: true
+		WorkingDir      string   `yaml:"working_dir"`       // default: service's working dir
+		User            string   `yaml:"user"`              // default: service's user
+		Mode            string   `yaml:"mode"`              // default: scenario's mode
+		Source          string   `yaml:"source"`            // default: scenario's source
+		ContinueOnError *bool    `yaml:"continue_on_error"` // default: scenario's policy
+	}
+)
+
+// Scenario parameter types
+const (
+	ParameterTypeString = "string"
+	ParameterTypeInt    = "int"
+	ParameterTypeBool   = "bool"
+)
+
+type (
+	ScenarioParameters map[string]ScenarioParameter
+	ScenarioParameter  struct {
+		Type        string   `yaml:"type"` // default: string
+		Default     any      `yaml:"default"`
+		Choices     []string `yaml:"choices"`
+		Description string   `yaml:"description"`
+		Required    bool     `yaml:"required"`
+	}
+)
+
+// Hook events
+const (
+	HookPreUp          = "pre-up"
+	HookPostUp         = "post-up"
+	HookPreDown        = "pre-down"
+	HookPostDown       = "post-down"
+	HookPostSourceSync = "post-source-sync"
+	HookPostMount      = "post-mount"
+	HookPostUmount     = "post-umount"
+)
+
+type (
+	HookConfigs map[string][]HookConfig // event -> hooks
+	HookConfig  struct {
+		Scenario        string   `yaml:"scenario"`          // scenario to run, mutually exclusive with command
+		Args            []string `yaml:"args"`              // args passed to the scenario
+		Command         []string `yaml:"command"`           // command to run on the host
+		Source          string   `yaml:"source"`            // default: project directory
+		WorkingDir      string   `yaml:"working_dir"`       // relative to the project or source directory
+		ContinueOnError bool     `yaml:"continue_on_error"` // don't fail the devbox command
 	}
 )
 
@@ -0,0 +1,100 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+var hookEvents = []string{
+	HookPreUp,
+	HookPostUp,
+	HookPreDown,
+	HookPostDown,
+	HookPostSourceSync,
+	HookPostMount,
+	HookPostUmount,
+}
+
+// HookPlan returns the steps to execute for the event. Scenario hooks are expanded into their scenario
+// plans, command hooks become host steps.
+func (p *Project) HookPlan(event string) ([]ScenarioPlanStep, error) {
+	plan := []ScenarioPlanStep{}
+
+	for i, hook := range p.Hooks[event] {
+		if hook.Scenario == "" {
+			plan = append(plan, ScenarioPlanStep{
+				ScenarioStep: ScenarioStep{
+					Name:       fmt.Sprintf("%s #%d", event, i+1),
+					Command:    hook.Command,
+					WorkingDir: hook.WorkingDir,
+					Mode:       ScenarioModeHost,
+					Source:     hook.Source,
+				},
+				ContinueOnError: hook.ContinueOnError,
+			})
+
+			continue
+		}
+
+		steps, err := p.ScenarioPlan(hook.Scenario, hook.Args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan %s hook #%d: %w", event, i+1, err)
+		}
+
+		for _, step := range steps {
+			step.ContinueOnError = step.ContinueOnError || hook.ContinueOnError
+			plan = append(plan, step)
+		}
+	}
+
+	return plan, nil
+}
+
+func validateHooks(hooks HookConfigs, scenarios ScenarioConfigs, sources SourceConfigs) error {
+	events := make([]string, 0, len(hooks))
+	for event := range hooks {
+		events = append(events, event)
+	}
+
+	sort.Strings(events)
+
+	for _, event := range events {
+		if !slices.Contains(hookEvents, event) {
+			return fmt.Errorf("unknown event %q", event)
+		}
+
+		for i, hook := range hooks[event] {
+			if err := validateHook(hook, scenarios, sources); err != nil {
+				return fmt.Errorf("%s hook #%d: %w", event, i+1, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateHook(hook HookConfig, scenarios ScenarioConfigs, sources SourceConfigs) error {
+	if hook.Scenario != "" {
+		if len(hook.Command) > 0 || hook.Source != "" || hook.WorkingDir != "" {
+			return errors.New("scenario can't be combined with command, source or working_dir")
+		}
+
+		if _, ok := scenarios[hook.Scenario]; !ok {
+			return fmt.Errorf("unknown scenario %q", hook.Scenario)
+		}
+
+		return nil
+	}
+
+	if len(hook.Command) == 0 {
+		return errors.New("either scenario or command is required")
+	}
+
+	if len(hook.Args) > 0 {
+		return errors.New("args are supported for scenarios only")
+	}
+
+	return validateScenarioTarget(ScenarioModeHost, "", hook.Source, hook.Command, sources)
+}
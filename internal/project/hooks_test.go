@@ -0,0 +1,93 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHookPlan(t *testing.T) {
+	p := &Project{
+		Scenarios: ScenarioConfigs{
+			"migrate": {Service: "api", Command: []string{"migrate"}},
+		},
+		Hooks: HookConfigs{
+			HookPostUp: {
+				{Scenario: "migrate", Args: []string{"--seed"}},
+				{Command: []string{"make", "proto"}, Source: "api", ContinueOnError: true},
+			},
+		},
+	}
+
+	plan, err := p.HookPlan(HookPostUp)
+	require.NoError(t, err)
+	require.Len(t, plan, 2)
+
+	assert.Equal(t, "migrate", plan[0].Name)
+	assert.Equal(t, []string{"migrate", "--seed"}, plan[0].Command)
+	assert.False(t, plan[0].ContinueOnError)
+
+	assert.Equal(t, "post-up #2", plan[1].Name)
+	assert.Equal(t, ScenarioModeHost, plan[1].Mode)
+	assert.Equal(t, "api", plan[1].Source)
+	assert.True(t, plan[1].ContinueOnError)
+
+	plan, err = p.HookPlan(HookPreDown)
+	require.NoError(t, err)
+	assert.Empty(t, plan)
+}
+
+func TestValidateHooks(t *testing.T) {
+	scenarios := ScenarioConfigs{"migrate": {Service: "api", Command: []string{"migrate"}}}
+	sources := SourceConfigs{"api": {}}
+
+	tests := []struct {
+		name    string
+		hooks   HookConfigs
+		wantErr string
+	}{
+		{
+			name: "valid",
+			hooks: HookConfigs{
+				HookPostSourceSync: {{Scenario: "migrate"}, {Command: []string{"make"}, Source: "api"}},
+			},
+		},
+		{
+			name:    "unknown event",
+			hooks:   HookConfigs{"after-up": {{Scenario: "migrate"}}},
+			wantErr: `unknown event "after-up"`,
+		},
+		{
+			name:    "unknown scenario",
+			hooks:   HookConfigs{HookPreUp: {{Scenario: "seed"}}},
+			wantErr: `pre-up hook #1: unknown scenario "seed"`,
+		},
+		{
+			name:    "scenario and command",
+			hooks:   HookConfigs{HookPreUp: {{Scenario: "migrate", Command: []string{"make"}}}},
+			wantErr: "pre-up hook #1: scenario can't be combined with command, source or working_dir",
+		},
+		{
+			name:    "empty",
+			hooks:   HookConfigs{HookPostMount: {{}}},
+			wantErr: "post-mount hook #1: either scenario or command is required",
+		},
+		{
+			name:    "unknown source",
+			hooks:   HookConfigs{HookPostUmount: {{Command: []string{"make"}, Source: "web"}}},
+			wantErr: `post-umount hook #1: unknown source "web"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateHooks(tt.hooks, scenarios, sources)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pilat/devbox/internal/app"
+)
+
+// SourcePath returns the directory of the source on the host: the local path when the source is mounted,
+// the synced checkout otherwise.
+func (p *Project) SourcePath(name string) string {
+	if localPath, ok := p.LocalMounts["./"+filepath.Join(app.SourcesDir, name)]; ok {
+		return localPath
+	}
+
+	return filepath.Join(p.WorkingDir, app.SourcesDir, name)
+}
+
+// HostWorkingDir returns the directory to run a host command in. A relative working dir is resolved
+// against the source directory if the step refers to a source, against the project directory otherwise.
+func (p *Project) HostWorkingDir(step ScenarioStep) string {
+	base := p.WorkingDir
+	if step.Source != "" {
+		base = p.SourcePath(step.Source)
+	}
+
+	if step.WorkingDir == "" {
+		return base
+	}
+
+	if filepath.IsAbs(step.WorkingDir) {
+		return step.WorkingDir
+	}
+
+	return filepath.Join(base, step.WorkingDir)
+}
+
+// HostEnvironment returns the environment for host commands: the current environment, the project
+// environment including .env and devbox variables with project and source locations.
+func (p *Project) HostEnvironment() []string {
+	env := os.Environ()
+
+	keys := make([]string, 0, len(p.Environment))
+	for k := range p.Environment {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		env = append(env, fmt.Sprintf("%s=%s", k, p.Environment[k]))
+	}
+
+	env = append(env,
+		"DEVBOX_PROJECT="+p.Name,
+		"DEVBOX_PROJECT_DIR="+p.WorkingDir,
+	)
+
+	sources := make([]string, 0, len(p.Sources))
+	for name := range p.Sources {
+		sources = append(sources, name)
+	}
+
+	sort.Strings(sources)
+
+	for _, name := range sources {
+		env = append(env, fmt.Sprintf("DEVBOX_SOURCE_%s=%s", convertToEnvName(name), p.SourcePath(name)))
+	}
+
+	return env
+}
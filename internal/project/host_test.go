@@ -0,0 +1,56 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostWorkingDir(t *testing.T) {
+	p := &Project{
+		Project:     &types.Project{WorkingDir: "/work"},
+		Sources:     SourceConfigs{"api": {}, "web": {}},
+		LocalMounts: map[string]string{"./sources/web": "/home/me/web"},
+	}
+
+	tests := []struct {
+		name string
+		step ScenarioStep
+		want string
+	}{
+		{name: "project dir", step: ScenarioStep{}, want: "/work"},
+		{name: "relative to project", step: ScenarioStep{WorkingDir: "scripts"}, want: "/work/scripts"},
+		{name: "synced source", step: ScenarioStep{Source: "api"}, want: "/work/sources/api"},
+		{name: "mounted source", step: ScenarioStep{Source: "web", WorkingDir: "proto"}, want: "/home/me/web/proto"},
+		{name: "absolute", step: ScenarioStep{Source: "api", WorkingDir: "/tmp"}, want: "/tmp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, p.HostWorkingDir(tt.step))
+		})
+	}
+}
+
+func TestHostEnvironment(t *testing.T) {
+	t.Setenv("DEVBOX_TEST_OUTER", "outer")
+
+	p := &Project{
+		Project: &types.Project{
+			Name:        "demo",
+			WorkingDir:  "/work",
+			Environment: types.Mapping{"DB_PORT": "5433"},
+		},
+		Sources:     SourceConfigs{"my-api": {}},
+		LocalMounts: map[string]string{},
+	}
+
+	env := p.HostEnvironment()
+
+	assert.Contains(t, env, "DEVBOX_TEST_OUTER=outer")
+	assert.Contains(t, env, "DB_PORT=5433")
+	assert.Contains(t, env, "DEVBOX_PROJECT=demo")
+	assert.Contains(t, env, "DEVBOX_PROJECT_DIR=/work")
+	assert.Contains(t, env, "DEVBOX_SOURCE_MY_API=/work/sources/my-api")
+}
@@ -0,0 +1,31 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pilat/devbox/internal/app"
+)
+
+// LinkTarget returns the directory a linked project points to. Such projects are symlinks to a directory
+// managed by the user, devbox never updates their manifest. The projects whose manifest is in a subdirectory
+// are symlinks too, but into the repos directory, so they aren't linked.
+func LinkTarget(workingDir string) (string, bool) {
+	info, err := os.Lstat(workingDir)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return "", false
+	}
+
+	target, err := filepath.EvalSymlinks(workingDir)
+	if err != nil {
+		return "", false
+	}
+
+	reposDir, err := filepath.EvalSymlinks(filepath.Join(app.AppDir, app.ReposDir))
+	if err == nil && strings.HasPrefix(target, reposDir+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return target, true
+}
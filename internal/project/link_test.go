@@ -0,0 +1,50 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pilat/devbox/internal/app"
+)
+
+func TestLinkTarget(t *testing.T) {
+	appDir := t.TempDir()
+	oldAppDir := app.AppDir
+	app.AppDir = appDir
+	t.Cleanup(func() { app.AppDir = oldAppDir })
+
+	manifestDir := filepath.Join(appDir, app.ReposDir, "shop", "environments", "shop")
+	localDir := t.TempDir()
+	for _, dir := range []string{manifestDir, filepath.Join(appDir, "cloned")} {
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+	}
+	require.NoError(t, os.Symlink(manifestDir, filepath.Join(appDir, "shop")))
+	require.NoError(t, os.Symlink(localDir, filepath.Join(appDir, "linked")))
+
+	resolvedLocalDir, err := filepath.EvalSymlinks(localDir)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		project    string
+		wantTarget string
+		wantLinked bool
+	}{
+		{name: "cloned project", project: "cloned"},
+		{name: "manifest in a subdirectory", project: "shop"},
+		{name: "linked directory", project: "linked", wantTarget: resolvedLocalDir, wantLinked: true},
+		{name: "missing project", project: "missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, linked := LinkTarget(filepath.Join(appDir, tt.project))
+			assert.Equal(t, tt.wantLinked, linked)
+			assert.Equal(t, tt.wantTarget, target)
+		})
+	}
+}
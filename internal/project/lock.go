@@ -0,0 +1,102 @@
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pilat/devbox/internal/app"
+)
+
+// LockFile pins every source to an exact commit. It lives next to the compose file and is meant to be
+// committed to the manifest repository.
+type LockFile struct {
+	Sources map[string]LockedSource `json:"sources"`
+}
+
+type LockedSource struct {
+	URL    string `json:"url"`
+	Branch string `json:"branch,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+	Commit string `json:"commit"`
+}
+
+// ErrLockNotFound is returned when the project has no lock file.
+var ErrLockNotFound = errors.New("lock file not found")
+
+func (p *Project) LoadLock() (*LockFile, error) {
+	content, err := os.ReadFile(filepath.Join(p.WorkingDir, app.LockFile))
+	if os.IsNotExist(err) {
+		return nil, ErrLockNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+
+	lock := &LockFile{}
+	if err := json.Unmarshal(content, lock); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lock file: %w", err)
+	}
+
+	if lock.Sources == nil {
+		lock.Sources = map[string]LockedSource{}
+	}
+
+	return lock, nil
+}
+
+func (p *Project) SaveLock(lock *LockFile) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lock file: %w", err)
+	}
+
+	filename := filepath.Join(p.WorkingDir, app.LockFile)
+	err = os.WriteFile(filename, append(data, '\n'), 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+
+	return nil
+}
+
+// LockedCommits returns the pinned commit of every source. It fails when a source is not pinned or its
+// pin was made for another URL or ref, so a frozen sync never silently falls back to a branch tip.
+func (p *Project) LockedCommits() (map[string]string, error) {
+	lock, err := p.LoadLock()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(p.Sources))
+	for name, src := range p.Sources {
+		// Only git sources can be pinned, an archive is pinned by its checksum already
+		if src.SourceType() == SourceTypeGit {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	commits := make(map[string]string, len(names))
+	for _, name := range names {
+		src := p.Sources[name]
+
+		locked, ok := lock.Sources[name]
+		if !ok || locked.Commit == "" {
+			return nil, fmt.Errorf("source %q is not pinned in %s", name, app.LockFile)
+		}
+
+		outdated := locked.URL != src.URL || locked.Branch != src.Branch || locked.Tag != src.Tag ||
+			(src.Commit != "" && locked.Commit != src.Commit)
+		if outdated {
+			return nil, fmt.Errorf("pin of source %q in %s is outdated", name, app.LockFile)
+		}
+
+		commits[name] = locked.Commit
+	}
+
+	return commits, nil
+}
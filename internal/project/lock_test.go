@@ -0,0 +1,98 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockRoundTrip(t *testing.T) {
+	p := &Project{Project: &types.Project{WorkingDir: t.TempDir()}}
+
+	_, err := p.LoadLock()
+	require.ErrorIs(t, err, ErrLockNotFound)
+
+	lock := &LockFile{Sources: map[string]LockedSource{
+		"api": {URL: "https://github.com/org/api.git", Branch: "main", Commit: "abc"},
+	}}
+	require.NoError(t, p.SaveLock(lock))
+
+	got, err := p.LoadLock()
+	require.NoError(t, err)
+	assert.Equal(t, lock, got)
+}
+
+func TestLockedCommits(t *testing.T) {
+	const lock = `{"sources": {
+		"api": {"url": "https://github.com/org/api.git", "branch": "main", "commit": "abc"},
+		"web": {"url": "https://github.com/org/web.git", "commit": "def"}
+	}}`
+
+	tests := []struct {
+		name    string
+		sources SourceConfigs
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "all pinned",
+			sources: SourceConfigs{
+				"api": {URL: "https://github.com/org/api.git", Branch: "main"},
+				"web": {URL: "https://github.com/org/web.git"},
+			},
+			want: map[string]string{"api": "abc", "web": "def"},
+		},
+		{
+			name: "non-git sources are not pinned",
+			sources: SourceConfigs{
+				"api":    {URL: "https://github.com/org/api.git", Branch: "main"},
+				"shared": {Type: SourceTypePath, Path: "/srv/shared"},
+			},
+			want: map[string]string{"api": "abc"},
+		},
+		{
+			name: "not pinned",
+			sources: SourceConfigs{
+				"api":    {URL: "https://github.com/org/api.git", Branch: "main"},
+				"worker": {URL: "https://github.com/org/worker.git"},
+			},
+			wantErr: `source "worker" is not pinned in devbox.lock`,
+		},
+		{
+			name: "branch changed",
+			sources: SourceConfigs{
+				"api": {URL: "https://github.com/org/api.git", Branch: "develop"},
+			},
+			wantErr: `pin of source "api" in devbox.lock is outdated`,
+		},
+		{
+			name: "switched to tag",
+			sources: SourceConfigs{
+				"web": {URL: "https://github.com/org/web.git", Tag: "v1.0.0"},
+			},
+			wantErr: `pin of source "web" in devbox.lock is outdated`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "devbox.lock"), []byte(lock), 0o644))
+
+			p := &Project{Project: &types.Project{WorkingDir: dir}, Sources: tt.sources}
+
+			got, err := p.LockedCommits()
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
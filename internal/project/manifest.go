@@ -0,0 +1,83 @@
+package project
+
+import (
+	"errors"
+	"time"
+)
+
+// maxManifestHistory limits the manifest revisions kept in the state file.
+const maxManifestHistory = 20
+
+// ErrNoPreviousManifest is returned by ManifestState.Rollback when there is nothing to roll back to.
+var ErrNoPreviousManifest = errors.New("no previous manifest revision recorded")
+
+// ManifestRevision is a commit the manifest repository was checked out at.
+type ManifestRevision struct {
+	Commit  string    `json:"commit"`
+	Subject string    `json:"subject,omitempty"`
+	Date    time.Time `json:"date"`
+}
+
+// ManifestState is what updates remember about the manifest repository. It is kept in the state file but read and
+// written apart from the rest of the project: the manifest may be broken while it is updated.
+type ManifestState struct {
+	// Branch is the branch updates track, it is detected on the first update
+	Branch string `json:"branch,omitempty"`
+
+	// Held is the commit the manifest is held at by `devbox update --to` or `--rollback`, up and restart don't
+	// move the manifest while it's set
+	Held string `json:"held,omitempty"`
+
+	// History lists the revisions the manifest was checked out at, oldest first, the last one is the current
+	History []ManifestRevision `json:"history,omitempty"`
+}
+
+// LoadManifestState returns an empty state when nothing was recorded yet.
+func LoadManifestState(workingDir string) (*ManifestState, error) {
+	state, err := readStateFile(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Manifest == nil {
+		return &ManifestState{}, nil
+	}
+
+	return state.Manifest, nil
+}
+
+// SaveManifestState replaces the manifest state, the rest of the state file is kept as is.
+func SaveManifestState(workingDir string, manifest *ManifestState) error {
+	state, err := readStateFile(workingDir)
+	if err != nil {
+		return err
+	}
+
+	state.Manifest = manifest
+
+	return writeStateFile(workingDir, state)
+}
+
+// Record appends the revision to the history unless it's the current one already.
+func (s *ManifestState) Record(rev ManifestRevision) {
+	if n := len(s.History); n > 0 && s.History[n-1].Commit == rev.Commit {
+		return
+	}
+
+	s.History = append(s.History, rev)
+	if len(s.History) > maxManifestHistory {
+		s.History = s.History[len(s.History)-maxManifestHistory:]
+	}
+}
+
+// Rollback drops the current revision from the history and returns the one before it. Rolling back again goes
+// further back.
+func (s *ManifestState) Rollback() (ManifestRevision, error) {
+	if len(s.History) < 2 {
+		return ManifestRevision{}, ErrNoPreviousManifest
+	}
+
+	s.History = s.History[:len(s.History)-1]
+
+	return s.History[len(s.History)-1], nil
+}
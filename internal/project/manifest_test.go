@@ -0,0 +1,70 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestStateRecord(t *testing.T) {
+	s := &ManifestState{}
+	s.Record(ManifestRevision{Commit: "aaa"})
+	s.Record(ManifestRevision{Commit: "aaa"})
+	s.Record(ManifestRevision{Commit: "bbb"})
+	assert.Equal(t, []ManifestRevision{{Commit: "aaa"}, {Commit: "bbb"}}, s.History)
+
+	for i := 0; i < maxManifestHistory; i++ {
+		s.Record(ManifestRevision{Commit: string(rune('c' + i))})
+	}
+
+	assert.Len(t, s.History, maxManifestHistory)
+	assert.Equal(t, "c", s.History[0].Commit)
+}
+
+func TestManifestStateRollback(t *testing.T) {
+	s := &ManifestState{History: []ManifestRevision{{Commit: "aaa"}, {Commit: "bbb"}, {Commit: "ccc"}}}
+
+	prev, err := s.Rollback()
+	require.NoError(t, err)
+	assert.Equal(t, "bbb", prev.Commit)
+
+	prev, err = s.Rollback()
+	require.NoError(t, err)
+	assert.Equal(t, "aaa", prev.Commit)
+
+	_, err = s.Rollback()
+	require.ErrorIs(t, err, ErrNoPreviousManifest)
+	assert.Equal(t, []ManifestRevision{{Commit: "aaa"}}, s.History)
+}
+
+func TestManifestStateKeepsProjectState(t *testing.T) {
+	dir := t.TempDir()
+
+	state, err := LoadManifestState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, &ManifestState{}, state)
+
+	p := &Project{
+		Project:         &types.Project{WorkingDir: dir},
+		LocalMounts:     map[string]string{"./sources/api": "/home/me/api"},
+		SourceOverrides: map[string]string{"api": "feature/login"},
+	}
+	require.NoError(t, p.SaveState())
+
+	manifest := &ManifestState{Branch: "main", Held: "bbb", History: []ManifestRevision{{Commit: "bbb"}}}
+	require.NoError(t, SaveManifestState(dir, manifest))
+
+	// Saving the project state must not drop the manifest state and the other way around
+	require.NoError(t, p.SaveState())
+
+	loaded := &Project{Project: &types.Project{WorkingDir: dir}}
+	require.NoError(t, loadState(loaded))
+	assert.Equal(t, p.LocalMounts, loaded.LocalMounts)
+	assert.Equal(t, p.SourceOverrides, loaded.SourceOverrides)
+
+	state, err = LoadManifestState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, state)
+}
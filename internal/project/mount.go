@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/pilat/devbox/internal/app"
 )
 
 func (p *Project) Mount(ctx context.Context, sources []string, path string) error {
@@ -45,3 +48,17 @@ func (p *Project) Mount(ctx context.Context, sources []string, path string) erro
 
 	return nil
 }
+
+// SourceMounts returns the local mounts that replace the source or a path inside it, by mount path.
+func (p *Project) SourceMounts(name string) map[string]string {
+	sourcePath := "./" + filepath.Join(app.SourcesDir, name)
+
+	mounts := map[string]string{}
+	for mountPath, localPath := range p.LocalMounts {
+		if mountPath == sourcePath || strings.HasPrefix(mountPath, sourcePath+"/") {
+			mounts[mountPath] = localPath
+		}
+	}
+
+	return mounts
+}
@@ -0,0 +1,40 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceMounts(t *testing.T) {
+	p := &Project{
+		LocalMounts: map[string]string{
+			"./sources/web":         "/home/me/web",
+			"./sources/api/cmd/app": "/home/me/api/cmd/app",
+			"./sources/api-gateway": "/home/me/gateway",
+		},
+	}
+
+	assert.Equal(t, map[string]string{"./sources/web": "/home/me/web"}, p.SourceMounts("web"))
+	assert.Equal(t, map[string]string{"./sources/api/cmd/app": "/home/me/api/cmd/app"}, p.SourceMounts("api"))
+	assert.Empty(t, p.SourceMounts("billing"))
+}
+
+func TestUnusedWorktrees(t *testing.T) {
+	p := &Project{
+		LocalMounts: map[string]string{
+			"./sources/api/cmd/app": "/devbox/demo/sources/.worktrees/api/feature-login/cmd/app",
+			"./sources/web":         "/home/me/web",
+		},
+		Worktrees: map[string]string{
+			"/devbox/demo/sources/.worktrees/api/feature-login": "/devbox/demo/sources/api",
+			"/devbox/demo/sources/.worktrees/api/feature":       "/devbox/demo/sources/api",
+			"/devbox/demo/sources/.worktrees/web/fix":           "/home/me/web",
+		},
+	}
+
+	assert.Equal(t, map[string]string{
+		"/devbox/demo/sources/.worktrees/api/feature": "/devbox/demo/sources/api",
+		"/devbox/demo/sources/.worktrees/web/fix":     "/home/me/web",
+	}, p.UnusedWorktrees())
+}
@@ -0,0 +1,46 @@
+package project
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckoutSource makes the syncs check out ref in the source instead of the configured ref, until ResetSource.
+// The ref is a branch name or a full ref, e.g. refs/pull/1/head. It is a per-user setting kept in the state file.
+func (p *Project) CheckoutSource(name, ref string) error {
+	src, ok := p.Sources[name]
+	if !ok {
+		return fmt.Errorf("source %q not found", name)
+	}
+
+	if src.SourceType() != SourceTypeGit {
+		return fmt.Errorf("source %q is not a git source", name)
+	}
+
+	if ref == "" || strings.ContainsAny(ref, " \t\n~^:?*[\\") || strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("invalid ref %q", ref)
+	}
+
+	p.SourceOverrides[name] = ref
+
+	if err := p.SaveState(); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	return nil
+}
+
+// ResetSource returns the source to the ref configured in the manifest.
+func (p *Project) ResetSource(name string) error {
+	if _, ok := p.SourceOverrides[name]; !ok {
+		return fmt.Errorf("source %q is not checked out at another ref", name)
+	}
+
+	delete(p.SourceOverrides, name)
+
+	if err := p.SaveState(); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	return nil
+}
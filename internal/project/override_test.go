@@ -0,0 +1,72 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckoutSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		ref     string
+		wantErr string
+	}{
+		{name: "branch", source: "api", ref: "feature/login"},
+		{name: "pull request ref", source: "api", ref: "refs/pull/42/head"},
+		{name: "unknown source", source: "web", ref: "main", wantErr: "not found"},
+		{name: "path source", source: "shared", ref: "main", wantErr: "not a git source"},
+		{name: "empty ref", source: "api", ref: "", wantErr: "invalid ref"},
+		{name: "option-like ref", source: "api", ref: "--force", wantErr: "invalid ref"},
+		{name: "ref with spaces", source: "api", ref: "my branch", wantErr: "invalid ref"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Project{
+				Project: &types.Project{WorkingDir: t.TempDir()},
+				Sources: SourceConfigs{
+					"api":    {URL: "https://github.com/org/api.git"},
+					"shared": {Type: SourceTypePath, Path: "/srv/shared"},
+				},
+				LocalMounts:     map[string]string{},
+				SourceOverrides: map[string]string{},
+			}
+
+			err := p.CheckoutSource(tt.source, tt.ref)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				assert.Empty(t, p.SourceOverrides)
+				return
+			}
+
+			require.NoError(t, err)
+
+			loaded := &Project{Project: &types.Project{WorkingDir: p.WorkingDir}}
+			require.NoError(t, loadState(loaded))
+			assert.Equal(t, map[string]string{tt.source: tt.ref}, loaded.SourceOverrides)
+		})
+	}
+}
+
+func TestResetSource(t *testing.T) {
+	p := &Project{
+		Project:         &types.Project{WorkingDir: t.TempDir()},
+		Sources:         SourceConfigs{"api": {URL: "https://github.com/org/api.git"}},
+		LocalMounts:     map[string]string{"./sources/api": "/home/me/api"},
+		SourceOverrides: map[string]string{},
+	}
+
+	require.ErrorContains(t, p.ResetSource("api"), "not checked out at another ref")
+
+	require.NoError(t, p.CheckoutSource("api", "feature"))
+	require.NoError(t, p.ResetSource("api"))
+
+	loaded := &Project{Project: &types.Project{WorkingDir: p.WorkingDir}}
+	require.NoError(t, loadState(loaded))
+	assert.Empty(t, loaded.SourceOverrides)
+	assert.Equal(t, p.LocalMounts, loaded.LocalMounts)
+}
@@ -0,0 +1,218 @@
+package project
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	validParameterNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	parameterPlaceholder    = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_-]+)\s*\}\}`)
+)
+
+// Names returns the sorted parameter names.
+func (params ScenarioParameters) Names() []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// DefaultValue returns the default value as a string, empty if there is no default.
+func (param ScenarioParameter) DefaultValue() string {
+	if param.Default == nil {
+		return ""
+	}
+
+	return fmt.Sprint(param.Default)
+}
+
+// TypeName returns the parameter type, string if not set.
+func (param ScenarioParameter) TypeName() string {
+	if param.Type == "" {
+		return ParameterTypeString
+	}
+
+	return param.Type
+}
+
+// Values returns the suggested values of the parameter.
+func (param ScenarioParameter) Values() []string {
+	if len(param.Choices) > 0 {
+		return param.Choices
+	}
+
+	if param.TypeName() == ParameterTypeBool {
+		return []string{"true", "false"}
+	}
+
+	return nil
+}
+
+func (param ScenarioParameter) validate(value string) error {
+	switch param.TypeName() {
+	case ParameterTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+	case ParameterTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+	case ParameterTypeString:
+	default:
+		return fmt.Errorf("unknown type %q", param.Type)
+	}
+
+	if len(param.Choices) > 0 && !slices.Contains(param.Choices, value) {
+		return fmt.Errorf("%q is not one of: %s", value, strings.Join(param.Choices, ", "))
+	}
+
+	return nil
+}
+
+// parseArgs consumes leading --name value, --name=value and bare boolean --name args. Parsing stops at
+// the first arg that is not a declared parameter or right after "--"; the rest is returned as is.
+func (params ScenarioParameters) parseArgs(args []string) (values map[string]string, rest []string, err error) {
+	values = map[string]string{}
+
+	i := 0
+	for ; len(params) > 0 && i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			i++
+			break
+		}
+
+		name, value, hasValue, ok := params.lookupArg(arg)
+		if !ok {
+			break
+		}
+
+		if !hasValue {
+			if params[name].TypeName() == ParameterTypeBool {
+				value = "true"
+			} else {
+				if i+1 >= len(args) {
+					return nil, nil, fmt.Errorf("parameter --%s requires a value", name)
+				}
+
+				i++
+				value = args[i]
+			}
+		}
+
+		values[name] = value
+	}
+
+	for _, name := range params.Names() {
+		param := params[name]
+
+		value, ok := values[name]
+		if !ok {
+			if param.Default == nil {
+				if param.Required {
+					return nil, nil, fmt.Errorf("missing required parameter --%s", name)
+				}
+
+				if param.TypeName() == ParameterTypeBool {
+					values[name] = "false"
+				} else {
+					values[name] = ""
+				}
+
+				continue
+			}
+
+			value = param.DefaultValue()
+			values[name] = value
+		}
+
+		if err := param.validate(value); err != nil {
+			return nil, nil, fmt.Errorf("invalid value for parameter --%s: %w", name, err)
+		}
+	}
+
+	return values, args[i:], nil
+}
+
+// lookupArg splits "--name" or "--name=value" and reports whether the name is a declared parameter.
+func (params ScenarioParameters) lookupArg(arg string) (name, value string, hasValue, ok bool) {
+	if !strings.HasPrefix(arg, "--") {
+		return "", "", false, false
+	}
+
+	name, value, hasValue = strings.Cut(arg[2:], "=")
+	_, ok = params[name]
+
+	return name, value, hasValue, ok
+}
+
+func (params ScenarioParameters) validate() error {
+	for _, name := range params.Names() {
+		param := params[name]
+
+		if !validParameterNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid parameter name %q", name)
+		}
+
+		switch param.TypeName() {
+		case ParameterTypeString, ParameterTypeInt, ParameterTypeBool:
+		default:
+			return fmt.Errorf("parameter %q has unknown type %q", name, param.Type)
+		}
+
+		for _, choice := range param.Choices {
+			if err := param.validate(choice); err != nil {
+				return fmt.Errorf("invalid choice of parameter %q: %w", name, err)
+			}
+		}
+
+		if param.Default == nil {
+			continue
+		}
+
+		if err := param.validate(param.DefaultValue()); err != nil {
+			return fmt.Errorf("invalid default of parameter %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// interpolate replaces {{name}} placeholders with parameter values. Unknown placeholders are kept as is.
+func interpolate(s string, values map[string]string) string {
+	if len(values) == 0 {
+		return s
+	}
+
+	return parameterPlaceholder.ReplaceAllStringFunc(s, func(m string) string {
+		name := parameterPlaceholder.FindStringSubmatch(m)[1]
+		if v, ok := values[name]; ok {
+			return v
+		}
+
+		return m
+	})
+}
+
+func interpolateSlice(s []string, values map[string]string) []string {
+	if s == nil || len(values) == 0 {
+		return s
+	}
+
+	result := make([]string, len(s))
+	for i, v := range s {
+		result[i] = interpolate(v, values)
+	}
+
+	return result
+}
@@ -0,0 +1,225 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs(t *testing.T) {
+	params := ScenarioParameters{
+		"env":     {Choices: []string{"dev", "test"}, Default: "dev"},
+		"count":   {Type: ParameterTypeInt, Default: 1},
+		"verbose": {Type: ParameterTypeBool},
+		"tag":     {},
+	}
+
+	tests := []struct {
+		name       string
+		params     ScenarioParameters
+		args       []string
+		wantValues map[string]string
+		wantRest   []string
+		wantErr    string
+	}{
+		{
+			name:       "defaults",
+			params:     params,
+			wantValues: map[string]string{"env": "dev", "count": "1", "verbose": "false", "tag": ""},
+		},
+		{
+			name:       "separate and inline values, bare bool, rest kept",
+			params:     params,
+			args:       []string{"--env", "test", "--count=3", "--verbose", "--other", "x"},
+			wantValues: map[string]string{"env": "test", "count": "3", "verbose": "true", "tag": ""},
+			wantRest:   []string{"--other", "x"},
+		},
+		{
+			name:       "separator ends parameters",
+			params:     params,
+			args:       []string{"--verbose=false", "--", "--env", "prod"},
+			wantValues: map[string]string{"env": "dev", "count": "1", "verbose": "false", "tag": ""},
+			wantRest:   []string{"--env", "prod"},
+		},
+		{
+			name:       "no parameters keeps separator",
+			args:       []string{"--", "x"},
+			wantValues: map[string]string{},
+			wantRest:   []string{"--", "x"},
+		},
+		{
+			name:    "choice",
+			params:  params,
+			args:    []string{"--env=prod"},
+			wantErr: `invalid value for parameter --env: "prod" is not one of: dev, test`,
+		},
+		{
+			name:    "int",
+			params:  params,
+			args:    []string{"--count", "many"},
+			wantErr: `invalid value for parameter --count: "many" is not an integer`,
+		},
+		{
+			name:    "missing value",
+			params:  params,
+			args:    []string{"--tag"},
+			wantErr: "parameter --tag requires a value",
+		},
+		{
+			name:    "required",
+			params:  ScenarioParameters{"name": {Required: true}},
+			wantErr: "missing required parameter --name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, rest, err := tt.params.parseArgs(tt.args)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantValues, values)
+			assert.Equal(t, tt.wantRest, rest)
+		})
+	}
+}
+
+func TestValidateParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  ScenarioParameters
+		wantErr string
+	}{
+		{
+			name: "valid",
+			params: ScenarioParameters{
+				"env":   {Choices: []string{"dev", "test"}, Default: "dev"},
+				"count": {Type: ParameterTypeInt, Default: 2},
+				"dry":   {Type: ParameterTypeBool, Default: true},
+			},
+		},
+		{
+			name:    "unknown type",
+			params:  ScenarioParameters{"a": {Type: "float"}},
+			wantErr: `parameter "a" has unknown type "float"`,
+		},
+		{
+			name:    "invalid name",
+			params:  ScenarioParameters{"a b": {}},
+			wantErr: `invalid parameter name "a b"`,
+		},
+		{
+			name:    "default not in choices",
+			params:  ScenarioParameters{"env": {Choices: []string{"dev"}, Default: "prod"}},
+			wantErr: `invalid default of parameter "env": "prod" is not one of: dev`,
+		},
+		{
+			name:    "choice of wrong type",
+			params:  ScenarioParameters{"n": {Type: ParameterTypeInt, Choices: []string{"one"}}},
+			wantErr: `invalid choice of parameter "n": "one" is not an integer`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScenarioPlanParameters(t *testing.T) {
+	p := &Project{Scenarios: ScenarioConfigs{
+		"setup": {
+			Service:    "db",
+			Command:    []string{"createdb", "{{db}}"},
+			Parameters: ScenarioParameters{"db": {Default: "app"}},
+		},
+		"migrate": {
+			Service:    "api",
+			DependsOn:  []string{"setup"},
+			Command:    []string{"migrate", "--env={{ env }}", "{{unknown}}", "{{.State}}"},
+			WorkingDir: "/app/{{env}}",
+			Parameters: ScenarioParameters{"env": {Default: "dev"}},
+		},
+	}}
+
+	plan, err := p.ScenarioPlan("migrate", []string{"--env", "test", "--db", "other"})
+	require.NoError(t, err)
+	require.Len(t, plan, 2)
+
+	assert.Equal(t, []string{"createdb", "app"}, plan[0].Command)
+	assert.Equal(t, []string{"migrate", "--env=test", "{{unknown}}", "{{.State}}", "--db", "other"}, plan[1].Command)
+	assert.Equal(t, "/app/test", plan[1].WorkingDir)
+}
+
+func TestGetScenarioParameters(t *testing.T) {
+	p := &Project{Scenarios: ScenarioConfigs{
+		"deploy": {Parameters: ScenarioParameters{
+			"env":    {Choices: []string{"dev", "test"}, Description: "Target environment"},
+			"dry":    {Type: ParameterTypeBool},
+			"target": {},
+		}},
+		"plain": {},
+	}}
+
+	tests := []struct {
+		name       string
+		scenario   string
+		args       []string
+		toComplete string
+		want       []string
+	}{
+		{
+			name: "names",
+			want: []string{"--dry\t", "--env\tTarget environment", "--target\t"},
+		},
+		{
+			name:       "names by prefix, used are skipped",
+			args:       []string{"--dry"},
+			toComplete: "--",
+			want:       []string{"--env\tTarget environment", "--target\t"},
+		},
+		{
+			name:       "value after separate flag",
+			args:       []string{"--env"},
+			toComplete: "t",
+			want:       []string{"test"},
+		},
+		{
+			name:       "inline value",
+			toComplete: "--dry=",
+			want:       []string{"--dry=true", "--dry=false"},
+		},
+		{
+			name:       "no suggestions after passthrough args",
+			args:       []string{"extra"},
+			toComplete: "--",
+			want:       []string{},
+		},
+		{
+			name:     "scenario without parameters",
+			scenario: "plain",
+			want:     []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scenario := tt.scenario
+			if scenario == "" {
+				scenario = "deploy"
+			}
+
+			assert.Equal(t, tt.want, p.GetScenarioParameters(scenario, tt.args, tt.toComplete))
+		})
+	}
+}
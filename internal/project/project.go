@@ -26,10 +26,13 @@ type Project struct {
 
 	Sources      SourceConfigs
 	Scenarios    ScenarioConfigs
+	Hooks        HookConfigs
 	HostEntities []string // IP hostname1 [hostname2] [hostname3] ...
 	CertConfig   CertConfig
 
-	LocalMounts map[string]string // some service's full mount path -> local path
+	LocalMounts     map[string]string // some service's full mount path -> local path
+	SourceOverrides map[string]string // source name -> ref checked out instead of the configured one
+	Worktrees       map[string]string // worktree created by devbox for a mount -> repository it belongs to
 
 	envFiles []string
 }
@@ -66,6 +69,7 @@ func New(ctx context.Context, projectName string, profiles []string) (*Project,
 		cli.WithProfiles(profiles),
 		cli.WithExtension("x-devbox-sources", SourceConfigs{}),
 		cli.WithExtension("x-devbox-scenarios", ScenarioConfigs{}),
+		cli.WithExtension("x-devbox-hooks", HookConfigs{}),
 		cli.WithExtension("x-devbox-hosts", HostConfigs{}),
 		cli.WithExtension("x-devbox-cert", CertConfig{}),
 		cli.WithExtension("x-devbox-default-stop-grace-period", Duration(0)),
@@ -80,15 +84,18 @@ func New(ctx context.Context, projectName string, profiles []string) (*Project,
 	}
 
 	p := &Project{
-		Project:     project,
-		envFiles:    o.EnvFiles,
-		LocalMounts: make(map[string]string),
+		Project:         project,
+		envFiles:        o.EnvFiles,
+		LocalMounts:     make(map[string]string),
+		SourceOverrides: make(map[string]string),
+		Worktrees:       make(map[string]string),
 	}
 
 	allFuncs := []func(p *Project) error{
 		loadState,
 		applySources,
 		applyScenarios,
+		applyHooks,
 		applyHosts,
 		applyCert,
 		setupGracePeriod,
@@ -118,23 +125,27 @@ func (p *Project) WithSelectedServices(names []string, options ...types.Dependen
 	}, nil
 }
 
-func (p *Project) SaveState() error {
-	state := &stateFileStruct{
-		Mounts: p.LocalMounts,
+// DisablePulls makes compose use the local images only: a missing image is built when the service can be built
+// and fails otherwise, instead of being pulled from a registry.
+func (p *Project) DisablePulls() {
+	for name, service := range p.Services {
+		service.PullPolicy = types.PullPolicyNever
+		p.Services[name] = service
 	}
+}
 
-	data, err := json.Marshal(state)
+func (p *Project) SaveState() error {
+	state, err := readStateFile(p.WorkingDir)
 	if err != nil {
-		return fmt.Errorf("failed to marshal state: %w", err)
+		return err
 	}
 
-	filename := filepath.Join(p.WorkingDir, app.StateFile)
-	err = os.WriteFile(filename, data, 0o644)
-	if err != nil {
-		return fmt.Errorf("failed to write state file: %w", err)
-	}
+	// The manifest state is written by UpdateManifestState and only kept here
+	state.Mounts = p.LocalMounts
+	state.Overrides = p.SourceOverrides
+	state.Worktrees = p.Worktrees
 
-	return nil
+	return writeStateFile(p.WorkingDir, state)
 }
 
 func (p *Project) Reload(ctx context.Context, profiles []string) error {
@@ -164,28 +175,61 @@ func (p *Project) absPath(source string) string {
 }
 
 func loadState(p *Project) error {
-	filename := filepath.Join(p.WorkingDir, app.StateFile)
+	state, err := readStateFile(p.WorkingDir)
+	if err != nil {
+		return err
+	}
+
+	if state.Mounts != nil {
+		p.LocalMounts = state.Mounts
+	}
+
+	if state.Overrides != nil {
+		p.SourceOverrides = state.Overrides
+	}
+
+	if state.Worktrees != nil {
+		p.Worktrees = state.Worktrees
+	}
+
+	return nil
+}
+
+// readStateFile returns an empty state when the project has no state file yet.
+func readStateFile(workingDir string) (*stateFileStruct, error) {
+	filename := filepath.Join(workingDir, app.StateFile)
 
 	_, err := os.Stat(filename)
 	if os.IsNotExist(err) {
-		return nil
+		return &stateFileStruct{}, nil
 	} else if err != nil {
-		return fmt.Errorf("failed to get state file: %w", err)
+		return nil, fmt.Errorf("failed to get state file: %w", err)
 	}
 
 	content, err := os.ReadFile(filename)
 	if err != nil {
-		return fmt.Errorf("failed to read state file: %w", err)
+		return nil, fmt.Errorf("failed to read state file: %w", err)
 	}
 
 	state := &stateFileStruct{}
 	err = json.Unmarshal(content, state)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal state: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
 	}
 
-	if state.Mounts != nil {
-		p.LocalMounts = state.Mounts
+	return state, nil
+}
+
+func writeStateFile(workingDir string, state *stateFileStruct) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	filename := filepath.Join(workingDir, app.StateFile)
+	err = os.WriteFile(filename, data, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
 	}
 
 	return nil
@@ -201,6 +245,18 @@ func applySources(p *Project) error {
 	if !ok {
 		return fmt.Errorf("unexpected type %T for x-devbox-sources extension", s)
 	}
+
+	if err := validateSources(sources); err != nil {
+		return fmt.Errorf("invalid x-devbox-sources: %w", err)
+	}
+
+	for name, source := range sources {
+		if source.Path != "" && !filepath.IsAbs(source.Path) {
+			source.Path = filepath.Join(p.WorkingDir, source.Path)
+			sources[name] = source
+		}
+	}
+
 	p.Sources = sources
 
 	return nil
@@ -216,11 +272,36 @@ func applyScenarios(p *Project) error {
 	if !ok {
 		return fmt.Errorf("unexpected type %T for x-devbox-scenarios extension", s)
 	}
+
+	if err := validateScenarios(scenarios, p.Sources); err != nil {
+		return fmt.Errorf("invalid x-devbox-scenarios: %w", err)
+	}
+
 	p.Scenarios = scenarios
 
 	return nil
 }
 
+func applyHooks(p *Project) error {
+	s, ok := p.Extensions["x-devbox-hooks"]
+	if !ok {
+		return nil
+	}
+
+	hooks, ok := s.(HookConfigs)
+	if !ok {
+		return fmt.Errorf("unexpected type %T for x-devbox-hooks extension", s)
+	}
+
+	if err := validateHooks(hooks, p.Scenarios, p.Sources); err != nil {
+		return fmt.Errorf("invalid x-devbox-hooks: %w", err)
+	}
+
+	p.Hooks = hooks
+
+	return nil
+}
+
 func applyHosts(p *Project) error {
 	s, ok := p.Extensions["x-devbox-hosts"]
 	if !ok {
@@ -365,6 +446,7 @@ func mountSourceVolumes(p *Project) error {
 
 	for name, service := range p.Services {
 		envPrefix := fmt.Sprintf("DEVBOX_%s_", convertToEnvName(service.Name))
+		usedSources := map[string]bool{} // source name -> locally mounted
 
 		for i := range service.Volumes {
 			volume := &service.Volumes[i]
@@ -378,11 +460,18 @@ func mountSourceVolumes(p *Project) error {
 				continue
 			}
 
+			source, _, _ := strings.Cut(strings.TrimPrefix(sourceName, "./sources/"), "/")
+			if _, ok := usedSources[source]; !ok {
+				usedSources[source] = false
+			}
+
 			altMountPath, ok := p.LocalMounts[sourceName]
 			if !ok {
 				continue
 			}
 
+			usedSources[source] = true
+
 			volume.Source = altMountPath
 
 			if service.Environment == nil {
@@ -394,12 +483,55 @@ func mountSourceVolumes(p *Project) error {
 			service.Environment[envPrefix+sourcePostfix] = &value
 		}
 
+		p.injectSourceEnvironment(&service, usedSources)
+
 		p.Services[name] = service
 	}
 
 	return nil
 }
 
+// injectSourceEnvironment adds the environment of the sources used by the service. Mounted sources get
+// their mountedEnvironment on top. Variables defined by the service itself are never overridden.
+func (p *Project) injectSourceEnvironment(service *types.ServiceConfig, usedSources map[string]bool) {
+	names := make([]string, 0, len(usedSources))
+	for name := range usedSources {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	lookup := func(key string) (string, bool) {
+		v, ok := p.Environment[key]
+		return v, ok
+	}
+
+	for _, name := range names {
+		src, ok := p.Sources[name]
+		if !ok {
+			continue
+		}
+
+		env := types.NewMappingWithEquals(src.Environment)
+		if usedSources[name] {
+			env.OverrideBy(types.NewMappingWithEquals(src.MountedEnvironment))
+		}
+
+		env = env.Resolve(lookup).RemoveEmpty()
+		for key, value := range env {
+			if _, exists := service.Environment[key]; exists {
+				continue
+			}
+
+			if service.Environment == nil {
+				service.Environment = types.MappingWithEquals{}
+			}
+
+			service.Environment[key] = value
+		}
+	}
+}
+
 func convertToEnvName(name string) string {
 	var result strings.Builder
 	prevWasUnderscore := false
@@ -0,0 +1,86 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountSourceVolumesEnvironment(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	bind := func(source, target string) types.ServiceVolumeConfig {
+		return types.ServiceVolumeConfig{Type: "bind", Source: source, Target: target}
+	}
+
+	p := &Project{
+		Project: &types.Project{
+			WorkingDir:  "/work",
+			Environment: types.Mapping{"API_TOKEN": "secret"},
+			Services: types.Services{
+				"api": {
+					Name:        "api",
+					Environment: types.MappingWithEquals{"LOG_LEVEL": str("warn")},
+					Volumes: []types.ServiceVolumeConfig{
+						bind("/work/sources/api/cmd", "/app"),
+						bind("/work/sources/api/config", "/config"),
+					},
+				},
+				"web": {
+					Name:    "web",
+					Volumes: []types.ServiceVolumeConfig{bind("/work/sources/web", "/app")},
+				},
+				"db": {
+					Name:    "db",
+					Volumes: []types.ServiceVolumeConfig{bind("/work/data", "/var/lib/data")},
+				},
+			},
+		},
+		Sources: SourceConfigs{
+			"api": {
+				Environment:        []string{"BUILD=release", "LOG_LEVEL=info", "API_TOKEN"},
+				MountedEnvironment: []string{"BUILD=dev"},
+			},
+			"web": {
+				Environment:        []string{"BUILD=release"},
+				MountedEnvironment: []string{"BUILD=dev"},
+			},
+		},
+		LocalMounts: map[string]string{"./sources/web": "/home/me/web"},
+	}
+
+	err := mountSourceVolumes(p)
+	assert.NoError(t, err)
+
+	assert.Equal(t, types.MappingWithEquals{
+		"BUILD":     str("release"),
+		"LOG_LEVEL": str("warn"),
+		"API_TOKEN": str("secret"),
+	}, p.Services["api"].Environment)
+
+	assert.Equal(t, types.MappingWithEquals{
+		"BUILD":                    str("dev"),
+		"DEVBOX_WEB___SOURCES_WEB": str("mounted"),
+	}, p.Services["web"].Environment)
+	assert.Equal(t, "/home/me/web", p.Services["web"].Volumes[0].Source)
+
+	assert.Nil(t, p.Services["db"].Environment)
+}
+
+func TestDisablePulls(t *testing.T) {
+	p := &Project{
+		Project: &types.Project{
+			Services: types.Services{
+				"api": {Name: "api", Image: "api:dev", PullPolicy: types.PullPolicyAlways},
+				"db":  {Name: "db", Image: "postgres:16"},
+			},
+		},
+	}
+
+	p.DisablePulls()
+
+	for name, service := range p.Services {
+		assert.Equal(t, types.PullPolicyNever, service.PullPolicy, name)
+	}
+}
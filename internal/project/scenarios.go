@@ -1,10 +1,19 @@
 package project
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
 
+// ScenarioPlanStep is a single step of a resolved scenario execution plan.
+type ScenarioPlanStep struct {
+	ScenarioStep
+
+	Scenario        string // scenario the step belongs to
+	ContinueOnError bool   // effective failure policy of the step
+}
+
 func (p *Project) GetScenarios(filter string) []string {
 	filter = strings.ToLower(filter)
 
@@ -19,3 +28,313 @@ func (p *Project) GetScenarios(filter string) []string {
 
 	return results
 }
+
+// ScenarioPlan returns the ordered list of steps to execute for the given scenario. Prerequisites
+// declared in depends_on come first, each scenario is included only once. Leading args matching
+// declared parameters are interpolated into the steps of the requested scenario, the rest is appended
+// to the command of its last step. Prerequisites use their parameter defaults.
+func (p *Project) ScenarioPlan(name string, args []string) ([]ScenarioPlanStep, error) {
+	if _, ok := p.Scenarios[name]; !ok {
+		return nil, fmt.Errorf("scenario %q not found", name)
+	}
+
+	plan := []ScenarioPlanStep{}
+	visited := map[string]bool{}
+	var rest []string
+
+	var visit func(current string) error
+	visit = func(current string) error {
+		if visited[current] {
+			return nil
+		}
+
+		visited[current] = true
+
+		s := p.Scenarios[current]
+		for _, dep := range s.DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		var scenarioArgs []string
+		if current == name {
+			scenarioArgs = args
+		}
+
+		values, remaining, err := s.Parameters.parseArgs(scenarioArgs)
+		if err != nil {
+			return fmt.Errorf("scenario %q: %w", current, err)
+		}
+
+		if current == name {
+			rest = remaining
+		}
+
+		for _, step := range s.steps(current) {
+			continueOnError := s.ContinueOnError
+			if step.ContinueOnError != nil {
+				continueOnError = *step.ContinueOnError
+			}
+
+			if step.Mode == "" {
+				step.Mode = s.Mode
+			}
+
+			if step.Mode == "" {
+				step.Mode = ScenarioModeAuto
+			}
+
+			if step.Source == "" {
+				step.Source = s.Source
+			}
+
+			step.Command = interpolateSlice(step.Command, values)
+			step.Entrypoint = interpolateSlice(step.Entrypoint, values)
+			step.WorkingDir = interpolate(step.WorkingDir, values)
+
+			plan = append(plan, ScenarioPlanStep{
+				ScenarioStep:    step,
+				Scenario:        current,
+				ContinueOnError: continueOnError,
+			})
+		}
+
+		return nil
+	}
+
+	if err := visit(name); err != nil {
+		return nil, err
+	}
+
+	if len(plan) == 0 {
+		return nil, fmt.Errorf("scenario %q has nothing to run", name)
+	}
+
+	if len(rest) > 0 {
+		last := len(plan) - 1
+		if plan[last].Scenario != name {
+			return nil, fmt.Errorf("scenario %q has no command to pass arguments to", name)
+		}
+
+		command := make([]string, 0, len(plan[last].Command)+len(rest))
+		command = append(command, plan[last].Command...)
+		command = append(command, rest...)
+		plan[last].Command = command
+	}
+
+	return plan, nil
+}
+
+// GetScenarioParameters returns completions for the parameters of the scenario: parameter names as
+// --name, or values of the parameter being typed. Nothing is suggested once passthrough args start.
+func (p *Project) GetScenarioParameters(scenario string, args []string, toComplete string) []string {
+	s, ok := p.Scenarios[scenario]
+	if !ok || len(s.Parameters) == 0 {
+		return []string{}
+	}
+
+	used := map[string]bool{}
+	for i := 0; i < len(args); i++ {
+		name, _, hasValue, ok := s.Parameters.lookupArg(args[i])
+		if !ok {
+			return []string{}
+		}
+
+		used[name] = true
+
+		if hasValue || s.Parameters[name].TypeName() == ParameterTypeBool {
+			continue
+		}
+
+		// The value of the last parameter is being completed
+		if i == len(args)-1 {
+			return filterPrefix(s.Parameters[name].Values(), "", toComplete)
+		}
+
+		i++
+	}
+
+	if name, value, hasValue, ok := s.Parameters.lookupArg(toComplete); ok && hasValue {
+		return filterPrefix(s.Parameters[name].Values(), "--"+name+"=", "--"+name+"="+value)
+	}
+
+	if toComplete != "" && !strings.HasPrefix(toComplete, "-") {
+		return []string{}
+	}
+
+	results := []string{}
+	for _, name := range s.Parameters.Names() {
+		flag := "--" + name
+		if used[name] || !strings.HasPrefix(flag, toComplete) {
+			continue
+		}
+
+		results = append(results, fmt.Sprintf("%s\t%s", flag, s.Parameters[name].Description))
+	}
+
+	return results
+}
+
+func filterPrefix(values []string, prefix, toComplete string) []string {
+	results := []string{}
+	for _, v := range values {
+		if strings.HasPrefix(prefix+v, toComplete) {
+			results = append(results, prefix+v)
+		}
+	}
+
+	return results
+}
+
+// steps returns the steps of the scenario. A scenario defined with a plain command is a single step.
+func (s ScenarioConfig) steps(name string) []ScenarioStep {
+	if len(s.Steps) > 0 {
+		steps := make([]ScenarioStep, len(s.Steps))
+		for i, step := range s.Steps {
+			if step.Name == "" {
+				step.Name = fmt.Sprintf("%s #%d", name, i+1)
+			}
+
+			steps[i] = step
+		}
+
+		return steps
+	}
+
+	if s.Service == "" && len(s.Command) == 0 && len(s.Entrypoint) == 0 {
+		return nil
+	}
+
+	return []ScenarioStep{{
+		Name:        name,
+		Service:     s.Service,
+		Command:     s.Command,
+		Entrypoint:  s.Entrypoint,
+		Tty:         s.Tty,
+		Interactive: s.Interactive,
+		WorkingDir:  s.WorkingDir,
+		User:        s.User,
+		Mode:        s.Mode,
+		Source:      s.Source,
+	}}
+}
+
+func validateScenarios(scenarios ScenarioConfigs, sources SourceConfigs) error {
+	for name, s := range scenarios {
+		if len(s.Steps) > 0 && (s.Service != "" || len(s.Command) > 0 || len(s.Entrypoint) > 0) {
+			return fmt.Errorf("scenario %q: steps can't be combined with service, command or entrypoint", name)
+		}
+
+		if err := s.Parameters.validate(); err != nil {
+			return fmt.Errorf("scenario %q: %w", name, err)
+		}
+
+		if !isValidScenarioMode(s.Mode) {
+			return fmt.Errorf("scenario %q: unknown mode %q", name, s.Mode)
+		}
+
+		if len(s.Steps) == 0 && (s.Service != "" || len(s.Command) > 0 || s.Source != "") {
+			if err := validateScenarioTarget(s.Mode, s.Service, s.Source, s.Command, sources); err != nil {
+				return fmt.Errorf("scenario %q: %w", name, err)
+			}
+		}
+
+		for i, step := range s.Steps {
+			if !isValidScenarioMode(step.Mode) {
+				return fmt.Errorf("scenario %q: step #%d has unknown mode %q", name, i+1, step.Mode)
+			}
+
+			mode := step.Mode
+			if mode == "" {
+				mode = s.Mode
+			}
+
+			source := step.Source
+			if source == "" {
+				source = s.Source
+			}
+
+			if err := validateScenarioTarget(mode, step.Service, source, step.Command, sources); err != nil {
+				return fmt.Errorf("scenario %q: step #%d: %w", name, i+1, err)
+			}
+		}
+
+		for _, dep := range s.DependsOn {
+			if _, ok := scenarios[dep]; !ok {
+				return fmt.Errorf("scenario %q depends on unknown scenario %q", name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	state := map[string]int{}
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("scenario dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		case done:
+			return nil
+		}
+
+		state[name] = visiting
+		for _, dep := range scenarios[name].DependsOn {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+
+		return nil
+	}
+
+	for name := range scenarios {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isValidScenarioMode(mode string) bool {
+	switch mode {
+	case "", ScenarioModeAuto, ScenarioModeExec, ScenarioModeRun, ScenarioModeHost:
+		return true
+	default:
+		return false
+	}
+}
+
+// validateScenarioTarget checks where a command runs: host commands may refer to a source, container
+// commands need a service.
+func validateScenarioTarget(mode, service, source string, command []string, sources SourceConfigs) error {
+	if mode != ScenarioModeHost {
+		if service == "" {
+			return errors.New("service is required")
+		}
+
+		if source != "" {
+			return fmt.Errorf("source is supported in %q mode only", ScenarioModeHost)
+		}
+
+		return nil
+	}
+
+	if len(command) == 0 {
+		return errors.New("command is required in host mode")
+	}
+
+	if _, ok := sources[source]; source != "" && !ok {
+		return fmt.Errorf("unknown source %q", source)
+	}
+
+	return nil
+}
@@ -0,0 +1,257 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScenarioPlan(t *testing.T) {
+	yes := true
+	no := false
+
+	scenarios := ScenarioConfigs{
+		"drop": {
+			Service: "postgres",
+			Command: []string{"dropdb", "app"},
+		},
+		"migrate": {
+			Service:   "api",
+			Command:   []string{"migrate"},
+			DependsOn: []string{"drop"},
+		},
+		"reset-db": {
+			DependsOn:       []string{"drop", "migrate"},
+			ContinueOnError: true,
+			Steps: []ScenarioStep{
+				{Name: "seed", Service: "worker", Command: []string{"seed"}},
+				{Service: "worker", Command: []string{"warmup"}, ContinueOnError: &no},
+			},
+		},
+		"all": {
+			DependsOn: []string{"reset-db"},
+		},
+		"lenient": {
+			Steps: []ScenarioStep{
+				{Service: "api", Command: []string{"lint"}, ContinueOnError: &yes},
+			},
+		},
+	}
+
+	type step struct {
+		scenario        string
+		name            string
+		service         string
+		command         []string
+		continueOnError bool
+	}
+
+	tests := []struct {
+		name     string
+		scenario string
+		args     []string
+		want     []step
+		wantErr  string
+	}{
+		{
+			name:     "single command scenario",
+			scenario: "drop",
+			args:     []string{"--force"},
+			want: []step{
+				{"drop", "drop", "postgres", []string{"dropdb", "app", "--force"}, false},
+			},
+		},
+		{
+			name:     "dependencies first, each scenario once, step policy overrides scenario policy",
+			scenario: "reset-db",
+			args:     []string{"--fast"},
+			want: []step{
+				{"drop", "drop", "postgres", []string{"dropdb", "app"}, false},
+				{"migrate", "migrate", "api", []string{"migrate"}, false},
+				{"reset-db", "seed", "worker", []string{"seed"}, true},
+				{"reset-db", "reset-db #2", "worker", []string{"warmup", "--fast"}, false},
+			},
+		},
+		{
+			name:     "step policy without scenario policy",
+			scenario: "lenient",
+			want: []step{
+				{"lenient", "lenient #1", "api", []string{"lint"}, true},
+			},
+		},
+		{
+			name:     "args require own steps",
+			scenario: "all",
+			args:     []string{"x"},
+			wantErr:  `scenario "all" has no command to pass arguments to`,
+		},
+		{
+			name:     "unknown scenario",
+			scenario: "missing",
+			wantErr:  `scenario "missing" not found`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Project{Scenarios: scenarios}
+
+			plan, err := p.ScenarioPlan(tt.scenario, tt.args)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			got := make([]step, len(plan))
+			for i, s := range plan {
+				got[i] = step{s.Scenario, s.Name, s.Service, s.Command, s.ContinueOnError}
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScenarioPlanDoesNotModifyConfig(t *testing.T) {
+	p := &Project{Scenarios: ScenarioConfigs{
+		"test": {Service: "api", Command: []string{"go", "test"}},
+	}}
+
+	_, err := p.ScenarioPlan("test", []string{"./..."})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"go", "test"}, p.Scenarios["test"].Command)
+}
+
+func TestScenarioPlanMode(t *testing.T) {
+	p := &Project{Scenarios: ScenarioConfigs{
+		"plain": {Service: "api", Command: []string{"true"}},
+		"oneoff": {
+			Mode: ScenarioModeRun,
+			Steps: []ScenarioStep{
+				{Service: "api", Command: []string{"true"}},
+				{Service: "api", Command: []string{"true"}, Mode: ScenarioModeExec},
+			},
+		},
+	}}
+
+	plan, err := p.ScenarioPlan("plain", nil)
+	require.NoError(t, err)
+	assert.Equal(t, ScenarioModeAuto, plan[0].Mode)
+
+	plan, err = p.ScenarioPlan("oneoff", nil)
+	require.NoError(t, err)
+	assert.Equal(t, ScenarioModeRun, plan[0].Mode)
+	assert.Equal(t, ScenarioModeExec, plan[1].Mode)
+}
+
+func TestValidateScenarios(t *testing.T) {
+	tests := []struct {
+		name      string
+		scenarios ScenarioConfigs
+		wantErr   string
+	}{
+		{
+			name: "valid",
+			scenarios: ScenarioConfigs{
+				"a": {Service: "api", Command: []string{"true"}},
+				"b": {DependsOn: []string{"a"}, Steps: []ScenarioStep{{Service: "api"}}},
+			},
+		},
+		{
+			name: "steps combined with command",
+			scenarios: ScenarioConfigs{
+				"a": {Service: "api", Command: []string{"true"}, Steps: []ScenarioStep{{Service: "api"}}},
+			},
+			wantErr: `scenario "a": steps can't be combined with service, command or entrypoint`,
+		},
+		{
+			name: "step without service",
+			scenarios: ScenarioConfigs{
+				"a": {Steps: []ScenarioStep{{Command: []string{"true"}}}},
+			},
+			wantErr: `scenario "a": step #1: service is required`,
+		},
+		{
+			name: "unknown mode",
+			scenarios: ScenarioConfigs{
+				"a": {Service: "api", Mode: "detach"},
+			},
+			wantErr: `scenario "a": unknown mode "detach"`,
+		},
+		{
+			name: "unknown step mode",
+			scenarios: ScenarioConfigs{
+				"a": {Steps: []ScenarioStep{{Service: "api", Mode: "remote"}}},
+			},
+			wantErr: `scenario "a": step #1 has unknown mode "remote"`,
+		},
+		{
+			name: "host scenario with source",
+			scenarios: ScenarioConfigs{
+				"a": {Mode: ScenarioModeHost, Source: "api", Command: []string{"make"}},
+				"b": {Mode: ScenarioModeHost, Steps: []ScenarioStep{{Command: []string{"make"}}}},
+			},
+		},
+		{
+			name: "host scenario without command",
+			scenarios: ScenarioConfigs{
+				"a": {Mode: ScenarioModeHost, Source: "api"},
+			},
+			wantErr: `scenario "a": command is required in host mode`,
+		},
+		{
+			name: "host step with unknown source",
+			scenarios: ScenarioConfigs{
+				"a": {Steps: []ScenarioStep{{Mode: ScenarioModeHost, Source: "web", Command: []string{"make"}}}},
+			},
+			wantErr: `scenario "a": step #1: unknown source "web"`,
+		},
+		{
+			name: "source in container mode",
+			scenarios: ScenarioConfigs{
+				"a": {Service: "api", Source: "api", Command: []string{"make"}},
+			},
+			wantErr: `scenario "a": source is supported in "host" mode only`,
+		},
+		{
+			name: "unknown dependency",
+			scenarios: ScenarioConfigs{
+				"a": {DependsOn: []string{"b"}},
+			},
+			wantErr: `scenario "a" depends on unknown scenario "b"`,
+		},
+		{
+			name: "self dependency",
+			scenarios: ScenarioConfigs{
+				"a": {DependsOn: []string{"a"}},
+			},
+			wantErr: "scenario dependency cycle: a -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateScenarios(tt.scenarios, SourceConfigs{"api": {}})
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateScenariosCycle(t *testing.T) {
+	err := validateScenarios(ScenarioConfigs{
+		"a": {DependsOn: []string{"b"}},
+		"b": {DependsOn: []string{"c"}},
+		"c": {DependsOn: []string{"a"}},
+	}, nil)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "scenario dependency cycle")
+}
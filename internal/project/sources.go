@@ -0,0 +1,198 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pilat/devbox/internal/app"
+)
+
+// Source types define where the source comes from
+const (
+	SourceTypeGit     = "git"     // a repository cloned from url
+	SourceTypePath    = "path"    // a directory on the host linked into the project
+	SourceTypeArchive = "archive" // a tar.gz or zip archive downloaded from url and extracted
+)
+
+// Kinds of refs a source can track
+const (
+	RefKindBranch = "branch"
+	RefKindTag    = "tag"
+	RefKindCommit = "commit"
+)
+
+// BackupsDir is the directory inside the sources directory that keeps backups of local changes
+const BackupsDir = ".backups"
+
+// WorktreesDir is the directory inside the sources directory that keeps worktrees created for mounts
+const WorktreesDir = ".worktrees"
+
+var (
+	commitHashRegex = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+	checksumRegex   = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+)
+
+// SourceType returns the type of the source, git when it is not set.
+func (s SourceConfig) SourceType() string {
+	if s.Type == "" {
+		return SourceTypeGit
+	}
+
+	return s.Type
+}
+
+// Ref returns the kind and the name of the ref the source tracks. An empty branch stands for the
+// default branch of the repository.
+func (s SourceConfig) Ref() (kind, name string) {
+	switch {
+	case s.Commit != "":
+		return RefKindCommit, s.Commit
+	case s.Tag != "":
+		return RefKindTag, s.Tag
+	default:
+		return RefKindBranch, s.Branch
+	}
+}
+
+// RemoteRef returns the full ref name to resolve in the remote repository, empty for a commit.
+func (s SourceConfig) RemoteRef() string {
+	switch kind, name := s.Ref(); {
+	case kind == RefKindCommit:
+		return ""
+	case kind == RefKindTag:
+		return "refs/tags/" + name
+	case name == "":
+		return "HEAD"
+	default:
+		return "refs/heads/" + name
+	}
+}
+
+// SourceBackupDir returns the directory for a backup of local changes in the source. It lives in the sources
+// directory, which is excluded from the project repository, so syncing the project itself never removes it.
+func (p *Project) SourceBackupDir(name string, t time.Time) string {
+	return filepath.Join(p.WorkingDir, app.SourcesDir, BackupsDir, name, t.Format("20060102-150405"))
+}
+
+// SourceWorktreeDir returns the directory for a worktree of the source with the branch checked out. Like backups, it
+// lives in the sources directory.
+func (p *Project) SourceWorktreeDir(name, branch string) string {
+	return filepath.Join(p.WorkingDir, app.SourcesDir, WorktreesDir, name, strings.ReplaceAll(branch, "/", "-"))
+}
+
+func validateSources(sources SourceConfigs) error {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		s := sources[name]
+
+		if strings.HasPrefix(name, ".") {
+			return fmt.Errorf("source %q: name must not start with a dot", name)
+		}
+
+		var err error
+		switch s.SourceType() {
+		case SourceTypeGit:
+			err = validateGitSource(s)
+		case SourceTypePath:
+			err = validatePathSource(s)
+		case SourceTypeArchive:
+			err = validateArchiveSource(s)
+		default:
+			err = fmt.Errorf("unknown type %q, expected one of: %s, %s, %s",
+				s.Type, SourceTypeGit, SourceTypePath, SourceTypeArchive)
+		}
+
+		if err != nil {
+			return fmt.Errorf("source %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateGitSource(s SourceConfig) error {
+	if s.URL == "" {
+		return errors.New("url is required")
+	}
+
+	if s.Path != "" || s.Checksum != "" {
+		return errors.New("path and checksum are not supported by git sources")
+	}
+
+	refs := 0
+	for _, ref := range []string{s.Branch, s.Tag, s.Commit} {
+		if ref != "" {
+			refs++
+		}
+	}
+
+	if refs > 1 {
+		return errors.New("only one of branch, tag or commit can be set")
+	}
+
+	if s.Commit != "" && !commitHashRegex.MatchString(s.Commit) {
+		return fmt.Errorf("commit must be a full lowercase commit hash, got %q", s.Commit)
+	}
+
+	if s.Depth < 0 {
+		return fmt.Errorf("depth must be positive, got %d", s.Depth)
+	}
+
+	if s.Depth > 0 && s.FullHistory {
+		return errors.New("depth and fullHistory can't be set together")
+	}
+
+	return nil
+}
+
+func validatePathSource(s SourceConfig) error {
+	if s.Path == "" {
+		return errors.New("path is required")
+	}
+
+	if s.URL != "" || s.Checksum != "" {
+		return errors.New("url and checksum are not supported by path sources")
+	}
+
+	return validateNoGitOptions(s)
+}
+
+func validateArchiveSource(s SourceConfig) error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "file" && u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("url must be a file://, http:// or https:// URL, got %q", s.URL)
+	}
+
+	if s.Checksum != "" && !checksumRegex.MatchString(s.Checksum) {
+		return fmt.Errorf("checksum must be in the form sha256:<hex>, got %q", s.Checksum)
+	}
+
+	if s.Path != "" {
+		return errors.New("path is not supported by archive sources")
+	}
+
+	return validateNoGitOptions(s)
+}
+
+func validateNoGitOptions(s SourceConfig) error {
+	gitOnly := s.Branch != "" || s.Tag != "" || s.Commit != "" || len(s.SparseCheckout) > 0 || s.Submodules ||
+		s.LFS || s.Depth != 0 || s.FullHistory || s.Filter != "" || s.SharedCache
+	if gitOnly {
+		return errors.New("branch, tag, commit, sparseCheckout, submodules, lfs, depth, fullHistory, filter " +
+			"and sharedCache are supported by git sources only")
+	}
+
+	return nil
+}
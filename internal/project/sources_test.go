@@ -0,0 +1,140 @@
+package project
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceRef(t *testing.T) {
+	const sha = "0123456789abcdef0123456789abcdef01234567"
+
+	tests := []struct {
+		name       string
+		source     SourceConfig
+		wantKind   string
+		wantName   string
+		wantRemote string
+	}{
+		{name: "default branch", source: SourceConfig{}, wantKind: RefKindBranch, wantName: "", wantRemote: "HEAD"},
+		{
+			name:       "branch",
+			source:     SourceConfig{Branch: "main"},
+			wantKind:   RefKindBranch,
+			wantName:   "main",
+			wantRemote: "refs/heads/main",
+		},
+		{
+			name:       "tag",
+			source:     SourceConfig{Tag: "v1.2.0"},
+			wantKind:   RefKindTag,
+			wantName:   "v1.2.0",
+			wantRemote: "refs/tags/v1.2.0",
+		},
+		{name: "commit", source: SourceConfig{Commit: sha}, wantKind: RefKindCommit, wantName: sha, wantRemote: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, name := tt.source.Ref()
+			assert.Equal(t, tt.wantKind, kind)
+			assert.Equal(t, tt.wantName, name)
+			assert.Equal(t, tt.wantRemote, tt.source.RemoteRef())
+		})
+	}
+}
+
+func TestValidateSources(t *testing.T) {
+	const repo = "https://github.com/org/api.git"
+
+	tests := []struct {
+		name    string
+		sources SourceConfigs
+		wantErr string
+	}{
+		{
+			name: "valid",
+			sources: SourceConfigs{
+				"api":    {URL: repo, Branch: "main", Depth: 50, Filter: "blob:none"},
+				"web":    {URL: repo, Tag: "v1.0.0", FullHistory: true},
+				"worker": {URL: repo, Commit: "0123456789abcdef0123456789abcdef01234567"},
+				"shared": {Type: SourceTypePath, Path: "/srv/shared"},
+				"vendor": {
+					Type:     SourceTypeArchive,
+					URL:      "https://example.com/vendor.tar.gz",
+					Checksum: "sha256:" + strings.Repeat("a", 64),
+				},
+			},
+		},
+		{
+			name:    "hidden name",
+			sources: SourceConfigs{".backups": {URL: repo}},
+			wantErr: `source ".backups": name must not start with a dot`,
+		},
+		{
+			name:    "branch and tag",
+			sources: SourceConfigs{"api": {URL: repo, Branch: "main", Tag: "v1.0.0"}},
+			wantErr: `source "api": only one of branch, tag or commit can be set`,
+		},
+		{
+			name:    "short commit",
+			sources: SourceConfigs{"api": {URL: repo, Commit: "0123456"}},
+			wantErr: `source "api": commit must be a full lowercase commit hash, got "0123456"`,
+		},
+		{
+			name:    "git without url",
+			sources: SourceConfigs{"api": {Branch: "main"}},
+			wantErr: `source "api": url is required`,
+		},
+		{
+			name:    "unknown type",
+			sources: SourceConfigs{"api": {Type: "svn", URL: repo}},
+			wantErr: `source "api": unknown type "svn", expected one of: git, path, archive`,
+		},
+		{
+			name:    "path without path",
+			sources: SourceConfigs{"shared": {Type: SourceTypePath}},
+			wantErr: `source "shared": path is required`,
+		},
+		{
+			name:    "path with branch",
+			sources: SourceConfigs{"shared": {Type: SourceTypePath, Path: "/srv/shared", Branch: "main"}},
+			wantErr: `source "shared": branch, tag, commit, sparseCheckout, submodules, lfs, depth, fullHistory, ` +
+				`filter and sharedCache are supported by git sources only`,
+		},
+		{
+			name:    "negative depth",
+			sources: SourceConfigs{"api": {URL: repo, Depth: -1}},
+			wantErr: `source "api": depth must be positive, got -1`,
+		},
+		{
+			name:    "depth with full history",
+			sources: SourceConfigs{"api": {URL: repo, Depth: 10, FullHistory: true}},
+			wantErr: `source "api": depth and fullHistory can't be set together`,
+		},
+		{
+			name:    "archive with ssh url",
+			sources: SourceConfigs{"vendor": {Type: SourceTypeArchive, URL: "git@github.com:org/vendor.git"}},
+			wantErr: `source "vendor": url must be a file://, http:// or https:// URL, got "git@github.com:org/vendor.git"`,
+		},
+		{
+			name: "archive with bad checksum",
+			sources: SourceConfigs{
+				"vendor": {Type: SourceTypeArchive, URL: "file:///tmp/vendor.zip", Checksum: "md5:abc"},
+			},
+			wantErr: `source "vendor": checksum must be in the form sha256:<hex>, got "md5:abc"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSources(tt.sources)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
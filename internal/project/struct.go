@@ -1,5 +1,8 @@
 package project
 
 type stateFileStruct struct {
-	Mounts map[string]string `json:"mounts"`
+	Mounts    map[string]string `json:"mounts"`
+	Overrides map[string]string `json:"overrides,omitempty"`
+	Worktrees map[string]string `json:"worktrees,omitempty"`
+	Manifest  *ManifestState    `json:"manifest,omitempty"`
 }
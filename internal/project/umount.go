@@ -3,6 +3,8 @@ package project
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"strings"
 )
 
 func (p *Project) Umount(ctx context.Context, sources []string) error {
@@ -20,3 +22,23 @@ func (p *Project) Umount(ctx context.Context, sources []string) error {
 
 	return nil
 }
+
+// UnusedWorktrees returns the worktrees created for mounts that no mount uses anymore, with their repositories.
+func (p *Project) UnusedWorktrees() map[string]string {
+	unused := map[string]string{}
+	for worktree, repo := range p.Worktrees {
+		used := false
+		for _, localPath := range p.LocalMounts {
+			if localPath == worktree || strings.HasPrefix(localPath, worktree+string(filepath.Separator)) {
+				used = true
+				break
+			}
+		}
+
+		if !used {
+			unused[worktree] = repo
+		}
+	}
+
+	return unused
+}
@@ -0,0 +1,278 @@
+// Package settings keeps the user preferences of devbox: the data root, limits and default behaviors. They are
+// read from a file in the user config directory, DEVBOX_HOME overrides the data root.
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pilat/devbox/internal/app"
+)
+
+// EnvHome overrides the home setting
+const EnvHome = "DEVBOX_HOME"
+
+// Sources of a setting value, see Source
+const (
+	SourceDefault = "default"
+	SourceFile    = "settings file"
+	SourceEnv     = EnvHome
+)
+
+// ErrUnknown is returned for a setting name that is not in the registry.
+var ErrUnknown = errors.New("unknown setting")
+
+// Values of the settings, they hold the defaults until Load
+var (
+	SyncConcurrency = 4                // sources synced at the same time
+	SyncTimeout     = 30 * time.Minute // limit for syncing all sources
+	UpTimeout       = 60 * time.Minute // limit for starting the services
+	LogsTail        = "500"            // lines of logs shown before following, "all" for everything
+	Offline         = false            // default of --offline
+	AutoSudo        = true             // retry with sudo when the hosts file or the CA need root
+)
+
+type setting struct {
+	description  string
+	defaultValue string
+	apply        func(value string) error // parses the value and sets the variable
+}
+
+var registry = map[string]setting{
+	"home": {
+		description:  "Directory with the projects, the CA and the shared cache",
+		defaultValue: app.AppDir,
+		apply: func(value string) error {
+			home, err := expandHome(value)
+			if err != nil {
+				return err
+			}
+
+			app.AppDir = home
+
+			return nil
+		},
+	},
+	"syncConcurrency": {
+		description:  "Number of sources synced at the same time",
+		defaultValue: strconv.Itoa(SyncConcurrency),
+		apply: func(value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return errors.New("must be a positive number")
+			}
+
+			SyncConcurrency = n
+
+			return nil
+		},
+	},
+	"syncTimeout": {
+		description:  "Time limit for syncing the sources, e.g. 30m",
+		defaultValue: SyncTimeout.String(),
+		apply:        durationSetter(&SyncTimeout),
+	},
+	"upTimeout": {
+		description:  "Time limit for starting the services, e.g. 1h",
+		defaultValue: UpTimeout.String(),
+		apply:        durationSetter(&UpTimeout),
+	},
+	"logsTail": {
+		description:  "Number of log lines shown before following, all for everything",
+		defaultValue: LogsTail,
+		apply: func(value string) error {
+			if n, err := strconv.Atoi(value); value != "all" && (err != nil || n < 0) {
+				return errors.New("must be a number or all")
+			}
+
+			LogsTail = value
+
+			return nil
+		},
+	},
+	"offline": {
+		description:  "Run up, restart and run offline by default",
+		defaultValue: strconv.FormatBool(Offline),
+		apply:        boolSetter(&Offline),
+	},
+	"autoSudo": {
+		description:  "Retry with sudo when updating the hosts file or installing the CA needs root",
+		defaultValue: strconv.FormatBool(AutoSudo),
+		apply:        boolSetter(&AutoSudo),
+	},
+}
+
+// values are the settings set in the file
+var values = map[string]string{}
+
+// Path returns the settings file, it lives in the user config directory because it may move the data root.
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+
+	return filepath.Join(dir, "devbox", "settings.json"), nil
+}
+
+// Load applies the settings file at path, a missing file leaves the defaults. DEVBOX_HOME wins over the file.
+func Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read settings: %w", err)
+	}
+
+	loaded := map[string]string{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &loaded); err != nil {
+			return fmt.Errorf("failed to parse settings %s: %w", path, err)
+		}
+	}
+
+	for name, value := range loaded {
+		s, ok := registry[name]
+		if !ok {
+			continue // e.g. written by a newer version
+		}
+
+		if err := s.apply(value); err != nil {
+			return fmt.Errorf("invalid setting %s in %s: %w", name, path, err)
+		}
+	}
+
+	values = loaded
+
+	if home := os.Getenv(EnvHome); home != "" {
+		if err := registry["home"].apply(home); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvHome, err)
+		}
+	}
+
+	return nil
+}
+
+// Names returns the names of all settings, sorted.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Get returns the value of a setting in effect and where it comes from.
+func Get(name string) (value, source string, err error) {
+	s, ok := registry[name]
+	if !ok {
+		return "", "", fmt.Errorf("%w: %s", ErrUnknown, name)
+	}
+
+	if name == "home" && os.Getenv(EnvHome) != "" {
+		return app.AppDir, SourceEnv, nil
+	}
+
+	if v, ok := values[name]; ok {
+		return v, SourceFile, nil
+	}
+
+	return s.defaultValue, SourceDefault, nil
+}
+
+// Describe returns the description of a setting.
+func Describe(name string) string {
+	return registry[name].description
+}
+
+// Set validates the value and writes it to the settings file at path. An empty value removes the setting, so its
+// default is used again.
+func Set(path, name, value string) error {
+	s, ok := registry[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknown, name)
+	}
+
+	updated := make(map[string]string, len(values)+1)
+	for k, v := range values {
+		updated[k] = v
+	}
+
+	if value == "" {
+		delete(updated, name)
+	} else {
+		if err := s.apply(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+
+		updated[name] = value
+	}
+
+	data, err := json.MarshalIndent(updated, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create settings directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+
+	values = updated
+
+	return nil
+}
+
+func durationSetter(target *time.Duration) func(string) error {
+	return func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return errors.New("must be a positive duration, e.g. 45m")
+		}
+
+		*target = d
+
+		return nil
+	}
+}
+
+func boolSetter(target *bool) func(string) error {
+	return func(value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+
+		*target = b
+
+		return nil
+	}
+}
+
+func expandHome(value string) (string, error) {
+	if rest, ok := strings.CutPrefix(value, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+
+		return filepath.Join(home, rest), nil
+	}
+
+	if !filepath.IsAbs(value) {
+		return "", errors.New("must be an absolute path")
+	}
+
+	return filepath.Clean(value), nil
+}
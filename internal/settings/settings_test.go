@@ -0,0 +1,130 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pilat/devbox/internal/app"
+)
+
+// restore puts the defaults back after a test changed the settings.
+func restore(t *testing.T) {
+	appDir, concurrency, syncTimeout, upTimeout, tail, offline, autoSudo :=
+		app.AppDir, SyncConcurrency, SyncTimeout, UpTimeout, LogsTail, Offline, AutoSudo
+
+	t.Cleanup(func() {
+		app.AppDir, SyncConcurrency, SyncTimeout, UpTimeout, LogsTail, Offline, AutoSudo =
+			appDir, concurrency, syncTimeout, upTimeout, tail, offline, autoSudo
+		values = map[string]string{}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		env     string
+		check   func(t *testing.T)
+		wantErr string
+	}{
+		{
+			name: "missing file keeps defaults",
+			check: func(t *testing.T) {
+				assert.Equal(t, 4, SyncConcurrency)
+				assert.Equal(t, "500", LogsTail)
+				assert.True(t, AutoSudo)
+			},
+		},
+		{
+			name:    "file",
+			content: `{"home": "/srv/devbox", "syncConcurrency": "8", "syncTimeout": "45m", "offline": "true"}`,
+			check: func(t *testing.T) {
+				assert.Equal(t, "/srv/devbox", app.AppDir)
+				assert.Equal(t, 8, SyncConcurrency)
+				assert.Equal(t, 45*time.Minute, SyncTimeout)
+				assert.True(t, Offline)
+
+				value, source, err := Get("syncConcurrency")
+				require.NoError(t, err)
+				assert.Equal(t, "8", value)
+				assert.Equal(t, SourceFile, source)
+			},
+		},
+		{
+			name:    "environment wins over file",
+			content: `{"home": "/srv/devbox"}`,
+			env:     "/data/devbox",
+			check: func(t *testing.T) {
+				assert.Equal(t, "/data/devbox", app.AppDir)
+
+				_, source, err := Get("home")
+				require.NoError(t, err)
+				assert.Equal(t, SourceEnv, source)
+			},
+		},
+		{
+			name:    "unknown settings are ignored",
+			content: `{"someday": "maybe"}`,
+		},
+		{name: "invalid number", content: `{"syncConcurrency": "0"}`, wantErr: "invalid setting syncConcurrency"},
+		{name: "relative home", content: `{"home": "devbox"}`, wantErr: "invalid setting home"},
+		{name: "broken file", content: `{`, wantErr: "failed to parse settings"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore(t)
+			t.Setenv(EnvHome, tt.env)
+
+			path := filepath.Join(t.TempDir(), "settings.json")
+			if tt.content != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+			}
+
+			err := Load(path)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			if tt.check != nil {
+				tt.check(t)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	restore(t)
+	t.Setenv(EnvHome, "")
+
+	path := filepath.Join(t.TempDir(), "devbox", "settings.json")
+
+	require.NoError(t, Set(path, "upTimeout", "2h"))
+	assert.Equal(t, 2*time.Hour, UpTimeout)
+
+	require.ErrorIs(t, Set(path, "colors", "on"), ErrUnknown)
+	require.ErrorContains(t, Set(path, "autoSudo", "sometimes"), "must be true or false")
+
+	// The file is read back by the next command
+	values = map[string]string{}
+	require.NoError(t, Load(path))
+
+	value, source, err := Get("upTimeout")
+	require.NoError(t, err)
+	assert.Equal(t, "2h", value)
+	assert.Equal(t, SourceFile, source)
+
+	require.NoError(t, Set(path, "upTimeout", ""))
+
+	value, source, err = Get("upTimeout")
+	require.NoError(t, err)
+	assert.Equal(t, "1h0m0s", value)
+	assert.Equal(t, SourceDefault, source)
+}
@@ -0,0 +1,365 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/project"
+)
+
+// dateFormat matches the default date format of git log
+const dateFormat = "Mon Jan 2 15:04:05 2006 -0700"
+
+// archiveBackend downloads a tar.gz or zip archive and extracts it into the source directory. The archive is
+// downloaded again on every sync unless its checksum is known and matches the extracted one.
+type archiveBackend struct {
+	url      string
+	checksum string
+	dir      string
+}
+
+// archiveMarker records which archive is extracted in the source directory
+type archiveMarker struct {
+	URL      string    `json:"url"`
+	Checksum string    `json:"checksum"`
+	SyncedAt time.Time `json:"syncedAt"`
+}
+
+func newArchiveBackend(cfg project.SourceConfig, opts Options) *archiveBackend {
+	return &archiveBackend{url: cfg.URL, checksum: cfg.Checksum, dir: opts.Dir}
+}
+
+func (b *archiveBackend) Sync(ctx context.Context) error {
+	if b.isUpToDate() {
+		return nil
+	}
+
+	extract, err := archiveExtractor(b.url)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(b.dir), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create sources directory: %w", err)
+	}
+
+	archiveFile := b.siblingPath("download")
+	defer func() { _ = os.Remove(archiveFile) }()
+
+	checksum, err := b.download(ctx, archiveFile)
+	if err != nil {
+		return fmt.Errorf("failed to download archive: %w", err)
+	}
+
+	if b.checksum != "" && b.checksum != checksum {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", b.url, b.checksum, checksum)
+	}
+
+	tmpDir := b.siblingPath("extract")
+	_ = os.RemoveAll(tmpDir)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	if err := extract(archiveFile, tmpDir); err != nil {
+		return fmt.Errorf("failed to extract archive: %w", err)
+	}
+
+	if err := os.RemoveAll(b.dir); err != nil {
+		return fmt.Errorf("failed to remove previous source: %w", err)
+	}
+
+	if err := os.Rename(tmpDir, b.dir); err != nil {
+		return fmt.Errorf("failed to move extracted archive: %w", err)
+	}
+
+	marker := archiveMarker{URL: b.url, Checksum: checksum, SyncedAt: time.Now()}
+	data, err := json.Marshal(marker)
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive marker: %w", err)
+	}
+
+	if err := os.WriteFile(b.siblingPath("archive.json"), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write archive marker: %w", err)
+	}
+
+	return nil
+}
+
+func (b *archiveBackend) GetInfo(_ context.Context) (*Info, error) {
+	marker, err := b.readMarker()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Info{
+		Message: path.Base(marker.URL),
+		Author:  "-",
+		Date:    marker.SyncedAt.Format(dateFormat),
+	}, nil
+}
+
+func (b *archiveBackend) isUpToDate() bool {
+	if b.checksum == "" {
+		return false
+	}
+
+	if _, err := os.Stat(b.dir); err != nil {
+		return false
+	}
+
+	marker, err := b.readMarker()
+
+	return err == nil && marker.URL == b.url && marker.Checksum == b.checksum
+}
+
+func (b *archiveBackend) readMarker() (*archiveMarker, error) {
+	data, err := os.ReadFile(b.siblingPath("archive.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive marker: %w", err)
+	}
+
+	marker := &archiveMarker{}
+	if err := json.Unmarshal(data, marker); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal archive marker: %w", err)
+	}
+
+	return marker, nil
+}
+
+// siblingPath returns a hidden path next to the source directory, so it never ends up in the source itself.
+func (b *archiveBackend) siblingPath(suffix string) string {
+	return filepath.Join(filepath.Dir(b.dir), "."+filepath.Base(b.dir)+"."+suffix)
+}
+
+// download saves the archive to filename and returns its checksum.
+func (b *archiveBackend) download(ctx context.Context, filename string) (string, error) {
+	u, err := url.Parse(b.url)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	var body io.ReadCloser
+	switch u.Scheme {
+	case "file":
+		body, err = os.Open(u.Path)
+		if err != nil {
+			return "", fmt.Errorf("failed to open archive: %w", err)
+		}
+	case "http", "https":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.url, http.NoBody)
+		if err != nil {
+			return "", fmt.Errorf("failed to create request: %w", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to send request: %w", checkUnreachable(err))
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+
+		body = resp.Body
+	default:
+		return "", fmt.Errorf("unsupported url scheme %q", u.Scheme)
+	}
+	defer func() { _ = body.Close() }()
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(file, hash), body); err != nil {
+		return "", fmt.Errorf("failed to save archive: %w", err)
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// checkUnreachable marks the error with git.ErrRemoteUnreachable when the host could not be connected to, so an
+// archive source goes offline the same way a git one does. An error response of the host is not marked.
+func checkUnreachable(err error) error {
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) {
+		return fmt.Errorf("%w: %w", git.ErrRemoteUnreachable, err)
+	}
+
+	return err
+}
+
+func archiveExtractor(rawURL string) (func(archive, dest string) error, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	switch name := strings.ToLower(u.Path); {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return extractTarGz, nil
+	case strings.HasSuffix(name, ".zip"):
+		return extractZip, nil
+	default:
+		return nil, fmt.Errorf("unsupported archive format of %s, expected .tar.gz, .tgz or .zip", rawURL)
+	}
+}
+
+func extractTarGz(archive, dest string) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to read gzip: %w", err)
+	}
+	defer func() { _ = gz.Close() }()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read tar: %w", err)
+		}
+
+		target, err := safeJoin(dest, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, os.ModePerm)
+		case tar.TypeReg:
+			err = writeFile(target, tr, header.FileInfo().Mode())
+		case tar.TypeSymlink:
+			err = writeSymlink(dest, target, header.Linkname)
+		default:
+			continue // devices, hard links and other special entries are not needed in sources
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractZip(archive, dest string) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("failed to open zip: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	for _, f := range zr.File {
+		target, err := safeJoin(dest, f.Name)
+		if err != nil {
+			return err
+		}
+
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+
+		if f.Mode()&os.ModeSymlink != 0 {
+			var link []byte
+			link, err = io.ReadAll(rc)
+			if err == nil {
+				err = writeSymlink(dest, target, string(link))
+			}
+		} else {
+			err = writeFile(target, rc, f.Mode())
+		}
+
+		_ = rc.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// safeJoin joins the archive entry name to dest and rejects names escaping it ("zip slip").
+func safeJoin(dest, name string) (string, error) {
+	target := filepath.Join(dest, name)
+	if !isWithin(dest, target) {
+		return "", fmt.Errorf("illegal path in archive: %s", name)
+	}
+
+	return target, nil
+}
+
+func isWithin(dir, target string) bool {
+	return target == dir || strings.HasPrefix(target, dir+string(os.PathSeparator))
+}
+
+func writeFile(target string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if _, err := io.Copy(file, r); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// writeSymlink creates a symlink, allowing only relative links that stay inside dest.
+func writeSymlink(dest, target, link string) error {
+	if filepath.IsAbs(link) || !isWithin(dest, filepath.Join(filepath.Dir(target), link)) {
+		return fmt.Errorf("illegal symlink in archive: %s -> %s", target, link)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := os.Symlink(link, target); err != nil {
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pilat/devbox/internal/project"
+)
+
+type archiveEntry struct {
+	name string
+	body string
+	link string // symlink target
+}
+
+func makeTarGz(t *testing.T, entries []archiveEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+		if e.link != "" {
+			header = &tar.Header{Name: e.name, Linkname: e.link, Typeflag: tar.TypeSymlink}
+		}
+
+		require.NoError(t, tw.WriteHeader(header))
+		_, err := tw.Write([]byte(e.body))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T, entries []archiveEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(e.body))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zw.Close())
+
+	return buf.Bytes()
+}
+
+func checksumOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestArchiveBackendSync(t *testing.T) {
+	files := []archiveEntry{
+		{name: "README.md", body: "vendor\n"},
+		{name: "lib/util.go", body: "package lib\n"},
+	}
+
+	tarGz := makeTarGz(t, files)
+	zipData := makeZip(t, files)
+
+	tests := []struct {
+		name     string
+		file     string
+		data     []byte
+		checksum string
+		wantErr  string
+	}{
+		{name: "tar.gz", file: "vendor.tar.gz", data: tarGz},
+		{name: "tgz with checksum", file: "vendor.tgz", data: tarGz, checksum: checksumOf(tarGz)},
+		{name: "zip", file: "vendor.zip", data: zipData},
+		{
+			name:     "checksum mismatch",
+			file:     "vendor.zip",
+			data:     zipData,
+			checksum: checksumOf(tarGz),
+			wantErr:  "checksum mismatch",
+		},
+		{name: "unsupported format", file: "vendor.rar", data: zipData, wantErr: "unsupported archive format"},
+		{
+			name:    "path escaping the source",
+			file:    "evil.tar.gz",
+			data:    makeTarGz(t, []archiveEntry{{name: "../evil", body: "x"}}),
+			wantErr: "illegal path in archive",
+		},
+		{
+			name:    "symlink escaping the source",
+			file:    "evil.tar.gz",
+			data:    makeTarGz(t, []archiveEntry{{name: "lib/link", link: "../../etc"}}),
+			wantErr: "illegal symlink in archive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(archive, tt.data, 0o644))
+
+			dir := filepath.Join(t.TempDir(), "sources", "vendor")
+			require.NoError(t, os.MkdirAll(dir, 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "stale.txt"), []byte("old"), 0o644))
+
+			cfg := project.SourceConfig{
+				Type:     project.SourceTypeArchive,
+				URL:      "file://" + archive,
+				Checksum: tt.checksum,
+			}
+			backend, err := New(cfg, Options{Dir: dir})
+			require.NoError(t, err)
+
+			err = backend.Sync(context.Background())
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				assert.FileExists(t, filepath.Join(dir, "stale.txt"), "failed sync must keep the previous content")
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NoFileExists(t, filepath.Join(dir, "stale.txt"))
+
+			content, err := os.ReadFile(filepath.Join(dir, "lib", "util.go"))
+			require.NoError(t, err)
+			assert.Equal(t, "package lib\n", string(content))
+
+			info, err := backend.GetInfo(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.file, info.Message)
+		})
+	}
+}
+
+func TestArchiveBackendDownload(t *testing.T) {
+	data := makeTarGz(t, []archiveEntry{{name: "main.go", body: "package main\n"}})
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/vendor.tar.gz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "sources", "vendor")
+	cfg := project.SourceConfig{
+		Type:     project.SourceTypeArchive,
+		URL:      server.URL + "/vendor.tar.gz",
+		Checksum: checksumOf(data),
+	}
+
+	backend, err := New(cfg, Options{Dir: dir})
+	require.NoError(t, err)
+
+	require.NoError(t, backend.Sync(context.Background()))
+	assert.FileExists(t, filepath.Join(dir, "main.go"))
+
+	// The extracted archive matches the checksum, so it's not downloaded again
+	require.NoError(t, backend.Sync(context.Background()))
+	assert.Equal(t, 1, requests)
+
+	cfg.URL = server.URL + "/missing.tar.gz"
+	backend, err = New(cfg, Options{Dir: dir})
+	require.NoError(t, err)
+	require.ErrorContains(t, backend.Sync(context.Background()), "unexpected status code: 404")
+}
@@ -0,0 +1,147 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pilat/devbox/internal/app"
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/project"
+)
+
+var (
+	_ ChangeTracker  = (*gitBackend)(nil)
+	_ StatusReporter = (*gitBackend)(nil)
+	_ HistoryReader  = (*gitBackend)(nil)
+	_ LastSyncReader = (*gitBackend)(nil)
+)
+
+// GitCacheDir returns the directory of the bare repositories shared between projects.
+func GitCacheDir() string {
+	return filepath.Join(app.AppDir, app.CacheDir, "git")
+}
+
+type gitBackend struct {
+	dir  string
+	repo git.Service
+	opts git.SyncOptions
+}
+
+func newGitBackend(cfg project.SourceConfig, opts Options) *gitBackend {
+	commit := cfg.Commit
+	if opts.Commit != "" {
+		commit = opts.Commit
+	}
+
+	cache := ""
+	if cfg.SharedCache {
+		cache = git.CachePath(GitCacheDir(), cfg.URL)
+	}
+
+	return &gitBackend{
+		dir:  opts.Dir,
+		repo: git.New(opts.Dir, opts.Excludes...),
+		opts: git.SyncOptions{
+			URL:            cfg.URL,
+			Branch:         cfg.Branch,
+			Tag:            cfg.Tag,
+			Commit:         commit,
+			Ref:            opts.Ref,
+			SparseCheckout: cfg.SparseCheckout,
+			Submodules:     cfg.Submodules,
+			LFS:            cfg.LFS,
+			Depth:          cfg.Depth,
+			FullHistory:    cfg.FullHistory,
+			Filter:         cfg.Filter,
+			Cache:          cache,
+		},
+	}
+}
+
+func (b *gitBackend) Sync(ctx context.Context) error {
+	if err := b.repo.Sync(ctx, b.opts); err != nil {
+		return fmt.Errorf("failed to sync repository: %w", err)
+	}
+
+	return nil
+}
+
+func (b *gitBackend) GetInfo(ctx context.Context) (*Info, error) {
+	info, err := b.repo.GetInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git info: %w", err)
+	}
+
+	return &Info{Message: info.Message, Author: info.Author, Date: info.Date}, nil
+}
+
+func (b *gitBackend) GetLocalChanges(ctx context.Context) (*git.LocalChanges, error) {
+	changes, err := b.repo.GetLocalChanges(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get local changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (b *gitBackend) Backup(ctx context.Context, dir string, changes *git.LocalChanges) ([]string, error) {
+	files, err := b.repo.Backup(ctx, dir, changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up local changes: %w", err)
+	}
+
+	return files, nil
+}
+
+func (b *gitBackend) GetStatus(ctx context.Context) (*git.Status, error) {
+	// Without its own .git the directory would be reported as a part of the project repository
+	if _, err := os.Stat(filepath.Join(b.dir, ".git")); err != nil {
+		return nil, ErrNotSynced
+	}
+
+	status, err := b.repo.GetStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git status: %w", err)
+	}
+
+	return status, nil
+}
+
+// GetHead returns the checked out commit, or nothing when the source was not synced yet.
+func (b *gitBackend) GetHead(ctx context.Context) (string, error) {
+	if _, err := os.Stat(filepath.Join(b.dir, ".git")); err != nil {
+		return "", nil
+	}
+
+	info, err := b.repo.GetInfo(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get git info: %w", err)
+	}
+
+	return info.Hash, nil
+}
+
+func (b *gitBackend) GetChangelog(ctx context.Context, from, to string) (*git.Changelog, error) {
+	changelog, err := b.repo.GetChangelog(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changelog: %w", err)
+	}
+
+	return changelog, nil
+}
+
+func (b *gitBackend) GetLastSync(ctx context.Context) (time.Time, error) {
+	if _, err := os.Stat(filepath.Join(b.dir, ".git")); err != nil {
+		return time.Time{}, ErrNotSynced
+	}
+
+	fetched, err := b.repo.GetLastFetch(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get last fetch: %w", err)
+	}
+
+	return fetched, nil
+}
@@ -0,0 +1,63 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/project"
+)
+
+// pathBackend links a directory on the host into the project. The directory is never modified.
+type pathBackend struct {
+	path string
+	dir  string
+}
+
+func newPathBackend(cfg project.SourceConfig, opts Options) *pathBackend {
+	return &pathBackend{path: cfg.Path, dir: opts.Dir}
+}
+
+func (b *pathBackend) Sync(_ context.Context) error {
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return fmt.Errorf("failed to access source directory: %w", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("source path %s is not a directory", b.path)
+	}
+
+	if target, err := os.Readlink(b.dir); err == nil && target == b.path {
+		return nil
+	}
+
+	// Whatever was synced here before (e.g. when the source type changed) is replaced by the link
+	if err := os.RemoveAll(b.dir); err != nil {
+		return fmt.Errorf("failed to remove previous source: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(b.dir), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create sources directory: %w", err)
+	}
+
+	if err := os.Symlink(b.path, b.dir); err != nil {
+		return fmt.Errorf("failed to link source directory: %w", err)
+	}
+
+	return nil
+}
+
+// GetInfo returns the last commit when the directory is a git checkout, otherwise just the path.
+func (b *pathBackend) GetInfo(ctx context.Context) (*Info, error) {
+	if info, err := git.New(b.path).GetInfo(ctx); err == nil {
+		return &Info{Message: info.Message, Author: info.Author, Date: info.Date}, nil
+	}
+
+	stat, err := os.Stat(b.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access source directory: %w", err)
+	}
+
+	return &Info{Message: b.path, Author: "-", Date: stat.ModTime().Format(dateFormat)}, nil
+}
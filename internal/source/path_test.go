@@ -0,0 +1,74 @@
+package source
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pilat/devbox/internal/project"
+)
+
+func TestPathBackendSync(t *testing.T) {
+	shared := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(shared, "main.go"), []byte("package main\n"), 0o644))
+
+	tests := []struct {
+		name    string
+		path    string
+		setup   func(t *testing.T, dir string)
+		wantErr string
+	}{
+		{name: "fresh link", path: shared},
+		{
+			name: "previous checkout is replaced",
+			path: shared,
+			setup: func(t *testing.T, dir string) {
+				require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+			},
+		},
+		{
+			name: "link to another directory is replaced",
+			path: shared,
+			setup: func(t *testing.T, dir string) {
+				require.NoError(t, os.MkdirAll(filepath.Dir(dir), 0o755))
+				require.NoError(t, os.Symlink(t.TempDir(), dir))
+			},
+		},
+		{
+			name:    "missing directory",
+			path:    filepath.Join(shared, "missing"),
+			wantErr: "failed to access source directory",
+		},
+		{name: "not a directory", path: filepath.Join(shared, "main.go"), wantErr: "is not a directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "sources", "shared")
+			if tt.setup != nil {
+				tt.setup(t, dir)
+			}
+
+			cfg := project.SourceConfig{Type: project.SourceTypePath, Path: tt.path}
+			backend, err := New(cfg, Options{Dir: dir})
+			require.NoError(t, err)
+
+			err = backend.Sync(context.Background())
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			target, err := os.Readlink(dir)
+			require.NoError(t, err)
+			assert.Equal(t, tt.path, target)
+			assert.FileExists(t, filepath.Join(dir, "main.go"))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pilat/devbox/internal/git"
+	"github.com/pilat/devbox/internal/project"
+)
+
+// Backend brings a source into its directory in the project and describes what is there.
+type Backend interface {
+	Sync(ctx context.Context) error
+	GetInfo(ctx context.Context) (*Info, error)
+}
+
+// ChangeTracker is implemented by backends whose sync can discard local work in the source directory.
+type ChangeTracker interface {
+	GetLocalChanges(ctx context.Context) (*git.LocalChanges, error)
+	Backup(ctx context.Context, dir string, changes *git.LocalChanges) ([]string, error)
+}
+
+// StatusReporter is implemented by backends that can compare the source with its remote.
+type StatusReporter interface {
+	GetStatus(ctx context.Context) (*git.Status, error)
+}
+
+// HistoryReader is implemented by backends that can tell which commits a sync brought into the source.
+type HistoryReader interface {
+	GetHead(ctx context.Context) (string, error)
+	GetChangelog(ctx context.Context, from, to string) (*git.Changelog, error)
+}
+
+// LastSyncReader is implemented by backends that can tell when the source was last synced from its remote.
+type LastSyncReader interface {
+	GetLastSync(ctx context.Context) (time.Time, error)
+}
+
+// PreviousCheckout returns the git checkout left in the source directory, e.g. when the source type changed from
+// git, so its local work can be backed up before a backend of another type replaces it. A linked directory is
+// never replaced, only the link is.
+func PreviousCheckout(dir string) (ChangeTracker, bool) {
+	if info, err := os.Lstat(dir); err != nil || !info.IsDir() {
+		return nil, false
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return nil, false
+	}
+
+	return &gitBackend{dir: dir, repo: git.New(dir)}, true
+}
+
+// ErrNotSynced is returned for a source that was not synced into the project yet.
+var ErrNotSynced = errors.New("source is not synced")
+
+// Info describes the current content of a source.
+type Info struct {
+	Message string
+	Author  string
+	Date    string
+}
+
+type Options struct {
+	Dir      string   // directory of the source in the project
+	Excludes []string // paths that git clean must preserve, see Project.SourceCleanExcludes
+	Commit   string   // pinned commit that overrides the configured ref of a git source
+	Ref      string   // ref from `devbox sources checkout` that overrides the configured ref of a git source
+}
+
+// New returns the backend for the type of the source.
+func New(cfg project.SourceConfig, opts Options) (Backend, error) {
+	switch cfg.SourceType() {
+	case project.SourceTypeGit:
+		return newGitBackend(cfg, opts), nil
+	case project.SourceTypePath:
+		return newPathBackend(cfg, opts), nil
+	case project.SourceTypeArchive:
+		return newArchiveBackend(cfg, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown source type %q", cfg.Type)
+	}
+}
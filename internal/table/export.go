@@ -7,5 +7,6 @@ type SortBy = table.SortBy
 var (
 	Asc             = table.Asc
 	AscAlphaNumeric = table.AscAlphaNumeric
+	AscNumeric      = table.AscNumeric
 	Dsc             = table.Dsc
 )